@@ -8,10 +8,17 @@ import (
 )
 
 func main() {
-	if err := di.RunService(); err != nil {
+	if err := run(os.Args[1:]); err != nil {
 		if _, writeErr := fmt.Fprintf(os.Stderr, "Application failed: %v\n", err); writeErr != nil {
 			panic(fmt.Sprintf("Application failed: %v (stderr write error: %v)", err, writeErr))
 		}
 		os.Exit(1)
 	}
 }
+
+func run(args []string) error {
+	if len(args) > 0 && args[0] == "replay" {
+		return di.RunReplay(args[1:])
+	}
+	return di.RunService()
+}
@@ -3,94 +3,280 @@ package cache
 import (
 	"context"
 	"errors"
-	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("adapters/cache")
+
 var (
 	ErrRepoNil         = errors.New("repo cannot be nil")
 	ErrContextCanceled = errors.New("context canceled")
+	ErrUnknownBackend  = errors.New("unknown cache backend")
 )
 
 type inMemoryCache struct {
-	log   logger.Logger
-	cache sync.Map
+	log         logger.Logger
+	store       *store
+	now         func() time.Time
+	cfg         config.CacheConfig
+	hits        atomic.Uint64
+	misses      atomic.Uint64
+	evictions   atomic.Uint64
+	expirations atomic.Uint64
+	// pending holds invalidations that arrive during a warm-up.
+	pending   map[string]int64
+	restoring int
+	mu        sync.Mutex
 }
 
-func NewInMemoryCache(log logger.Logger) ports.Cache {
+func NewInMemoryCache(cfg config.CacheConfig, log logger.Logger) ports.Cache {
+	if cfg.Policy != PolicyLRU && cfg.Policy != PolicyLFU {
+		log.Warn("unknown cache eviction policy, using lru", "policy", cfg.Policy)
+		cfg.Policy = PolicyLRU
+	}
+
 	return &inMemoryCache{
 		log:   log,
-		cache: sync.Map{},
+		store: newStore(cfg),
+		now:   time.Now,
+		cfg:   cfg,
 	}
 }
 
-func (c *inMemoryCache) Get(orderUID string) (*domain.Order, bool) {
+func (c *inMemoryCache) Get(ctx context.Context, orderUID string) (order *domain.Order, found bool) {
+	ctx, span := tracer.Start(ctx, "cache.Get", trace.WithAttributes(attribute.String("order.uid", orderUID)))
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", found))
+		span.End()
+	}()
+
 	if orderUID == "" {
-		c.log.Warn("attempt to get order with empty orderUID")
+		c.log.WithContext(ctx).Warn("attempt to get order with empty orderUID")
 		return nil, false
 	}
 
-	val, found := c.cache.Load(orderUID)
-	if !found {
-		c.log.Debug("order not found in cache", "order_uid", orderUID)
-		return nil, false
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	order, ok := val.(*domain.Order)
-	if !ok {
-		c.log.Error("invalid type in cache", "order_uid", orderUID)
+	cached, exists := c.store.entries[orderUID]
+	if exists && cached.expired(c.now()) {
+		c.store.remove(cached)
+		c.expirations.Add(1)
+		exists = false
+	}
+	if !exists {
+		c.misses.Add(1)
+		c.log.WithContext(ctx).Debug("order not found in cache", "order_uid", orderUID)
 		return nil, false
 	}
 
-	c.log.Debug("order retrieved from cache", "order_uid", orderUID)
-	return order, true
+	c.store.policy.touch(cached)
+	c.hits.Add(1)
+	c.log.WithContext(ctx).Debug("order retrieved from cache", "order_uid", orderUID)
+	return cached.order, true
 }
 
-func (c *inMemoryCache) Set(order *domain.Order) {
+func (c *inMemoryCache) Set(ctx context.Context, order *domain.Order) {
+	ctx, span := tracer.Start(ctx, "cache.Set")
+	defer span.End()
+
 	if order == nil || order.OrderUID == "" || order.DateCreated.IsZero() {
-		c.log.Warn("attempt to save invalid order")
+		c.log.WithContext(ctx).Warn("attempt to save invalid order")
 		return
 	}
 
-	c.cache.Store(order.OrderUID, order)
-	c.log.Debug("order saved in cache", "order_uid", order.OrderUID)
-}
+	added, ok := c.newEntry(order)
+	if !ok {
+		c.log.WithContext(ctx).Warn("order exceeds the cache memory budget, not cached", "order_uid", order.OrderUID, "size", added.size)
+		return
+	}
+
+	c.mu.Lock()
+	evicted, stored := c.insertLocked(c.store, added)
+	c.mu.Unlock()
 
-func (c *inMemoryCache) RestoreFromDB(ctx context.Context, repo ports.OrderRepository) error {
-	if repo == nil {
-		return fmt.Errorf("repo nil: %w", ErrRepoNil)
+	if !stored {
+		c.log.WithContext(ctx).Debug("cache already holds this or a newer order version", "order_uid", order.OrderUID, "version", order.Version)
+		return
 	}
+	c.log.WithContext(ctx).Debug("order saved in cache", "order_uid", order.OrderUID, "evicted", evicted)
+}
 
-	const limit = 1000
+// Find reports complete when the cached orders are all orders carrying key.
+func (c *inMemoryCache) Find(ctx context.Context, index domain.OrderIndex, key string) (orders []*domain.Order, complete bool) {
+	ctx, span := tracer.Start(ctx, "cache.Find", trace.WithAttributes(
+		attribute.String("cache.index", string(index)),
+		attribute.String("cache.key", key),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", complete), attribute.Int("cache.orders", len(orders)))
+		span.End()
+	}()
 
-	c.cache.Clear()
-	c.log.Debug("cache cleared before restore")
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if err := ctx.Err(); err != nil {
-		return fmt.Errorf("context canceled before query: %w", ErrContextCanceled)
+	secondary, exists := c.store.indexes[index]
+	if !exists {
+		c.log.WithContext(ctx).Warn("lookup by unknown cache index", "index", index)
+		return nil, false
+	}
+
+	uids, complete := secondary.lookup(key)
+	now := c.now()
+	orders = make([]*domain.Order, 0, len(uids))
+	for _, uid := range uids {
+		cached, exists := c.store.entries[uid]
+		if !exists {
+			complete = false
+			continue
+		}
+		if cached.expired(now) {
+			c.store.remove(cached)
+			c.expirations.Add(1)
+			complete = false
+			continue
+		}
+		c.store.policy.touch(cached)
+		orders = append(orders, cached.order)
 	}
 
-	orders, err := repo.ListRecent(ctx, limit)
-	if err != nil {
-		c.log.Error("failed to restore cache from DB", "error", err, "limit", limit)
-		return fmt.Errorf("restore cache from DB: %w", err)
+	if !complete {
+		c.misses.Add(1)
+		return orders, false
 	}
+	c.hits.Add(1)
+	c.log.WithContext(ctx).Debug("orders retrieved from cache index", "index", index, "key", key, "orders", len(orders))
+	return orders, true
+}
+
+// SetComplete marks key complete only if every order fitted in the cache.
+func (c *inMemoryCache) SetComplete(ctx context.Context, index domain.OrderIndex, key string, orders []*domain.Order) {
+	ctx, span := tracer.Start(ctx, "cache.SetComplete", trace.WithAttributes(attribute.String("cache.index", string(index))))
+	defer span.End()
 
+	added := make([]*entry, 0, len(orders))
 	for _, order := range orders {
-		if err := ctx.Err(); err != nil {
-			c.log.Warn("context canceled during cache restore", "processed_orders", len(orders))
-			return fmt.Errorf("context canceled during restore: %w", ErrContextCanceled)
+		if order == nil || order.OrderUID == "" || order.DateCreated.IsZero() {
+			continue
+		}
+		if candidate, ok := c.newEntry(order); ok {
+			added = append(added, candidate)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, candidate := range added {
+		c.insertLocked(c.store, candidate)
+	}
+
+	secondary, exists := c.store.indexes[index]
+	if !exists || len(added) != len(orders) {
+		return
+	}
+	for _, candidate := range added {
+		cached, exists := c.store.entries[candidate.key]
+		if !exists || cached.order.Version != candidate.order.Version {
+			return
 		}
+	}
+	secondary.markComplete(key)
+	c.log.WithContext(ctx).Debug("orders cached as complete index result", "index", index, "key", key, "orders", len(added))
+}
+
+// Invalidate drops the cached order when it is older than the announced version,
+// along with the complete marks of the keys it is listed under.
+func (c *inMemoryCache) Invalidate(ctx context.Context, change domain.OrderChange) {
+	c.mu.Lock()
+	unmarkChanged(c.store, change)
+	dropped := invalidate(c.store, change)
+	if c.pending != nil && change.Version > c.pending[change.OrderUID] {
+		c.pending[change.OrderUID] = change.Version
+	}
+	c.mu.Unlock()
+
+	if dropped {
+		c.log.WithContext(ctx).Debug("stale order dropped from cache", "order_uid", change.OrderUID, "version", change.Version)
+	}
+}
+
+func invalidate(target *store, change domain.OrderChange) bool {
+	cached, exists := target.entries[change.OrderUID]
+	if !exists || cached.order.Version >= change.Version {
+		return false
+	}
+	target.remove(cached)
+	return true
+}
 
-		if order != nil && order.OrderUID != "" {
-			c.Set(order)
+// unmarkChanged clears the marks of change's keys, or all of them when unknown.
+func unmarkChanged(target *store, change domain.OrderChange) {
+	if cached, exists := target.entries[change.OrderUID]; exists && cached.order.Version >= change.Version {
+		return
+	}
+	for name, index := range target.indexes {
+		switch {
+		case name.Unique():
+		case change.Keys == nil:
+			clear(index.complete)
+		default:
+			index.unmark(change.Keys[name]...)
 		}
 	}
+}
+
+// reset drops every entry, for when the contents can no longer be trusted.
+func (c *inMemoryCache) reset() {
+	c.mu.Lock()
+	c.store = newStore(c.cfg)
+	c.mu.Unlock()
+}
+
+func (c *inMemoryCache) newEntry(order *domain.Order) (*entry, bool) {
+	now := c.now()
+	added := &entry{key: order.OrderUID, order: order, size: estimateSize(order), storedAt: now}
+	if c.cfg.TTL > 0 {
+		added.expiresAt = now.Add(c.cfg.TTL)
+	}
+	return added, c.cfg.MaxBytes <= 0 || added.size <= c.cfg.MaxBytes
+}
+
+// insertLocked keeps a live entry that holds the same or a newer version.
+func (c *inMemoryCache) insertLocked(target *store, added *entry) (evicted int, stored bool) {
+	now := c.now()
+	if cached, exists := target.entries[added.key]; exists && !cached.expired(now) && cached.order.Version >= added.order.Version {
+		return 0, false
+	}
 
-	c.log.Info("cache restored from DB", "orders_count", len(orders), "limit", limit)
-	return nil
+	evicted, expired := target.insert(added, now)
+	c.evictions.Add(uint64(evicted))
+	c.expirations.Add(uint64(expired))
+	return evicted, true
+}
+
+func (c *inMemoryCache) Stats() domain.CacheStats {
+	c.mu.Lock()
+	size, bytes := len(c.store.entries), c.store.bytes
+	c.mu.Unlock()
+
+	return domain.CacheStats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Evictions:   c.evictions.Load(),
+		Expirations: c.expirations.Load(),
+		Bytes:       bytes,
+		Size:        size,
+	}
 }
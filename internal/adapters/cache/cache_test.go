@@ -0,0 +1,193 @@
+package cache
+
+import (
+	"context"
+	"iter"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"go.uber.org/zap"
+)
+
+// fakeRepository yields its orders newest first, like the database.
+type fakeRepository struct {
+	ports.OrderRepository
+	orders []*domain.Order
+}
+
+func (r *fakeRepository) IterateRecent(_ context.Context, limit, _ int) iter.Seq2[*domain.Order, error] {
+	return func(yield func(*domain.Order, error) bool) {
+		for i, order := range r.orders {
+			if limit > 0 && i >= limit {
+				return
+			}
+			if !yield(order, nil) {
+				return
+			}
+		}
+	}
+}
+
+func (r *fakeRepository) IterateChangesSince(context.Context, time.Time) iter.Seq2[domain.OrderChange, error] {
+	return func(func(domain.OrderChange, error) bool) {}
+}
+
+func nopLogger() logger.Logger {
+	zapLogger := zap.NewNop()
+	return &logger.ZapLogger{Logger: zapLogger, Sugared: zapLogger.Sugar()}
+}
+
+func testOrder(uid string, version int64) *domain.Order {
+	return &domain.Order{
+		OrderUID:    uid,
+		TrackNumber: "TRACK-" + uid,
+		CustomerID:  "customer",
+		DateCreated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Version:     version,
+		Items:       []domain.Item{{RID: "rid-" + uid, NmID: 1}},
+	}
+}
+
+func cachedUIDs(t *testing.T, c ports.Cache, uids ...string) []string {
+	t.Helper()
+
+	var found []string
+	for _, uid := range uids {
+		if _, ok := c.Get(t.Context(), uid); ok {
+			found = append(found, uid)
+		}
+	}
+	return found
+}
+
+func TestRestoreFromDBEvictsOldestFirst(t *testing.T) {
+	for _, policy := range []string{PolicyLRU, PolicyLFU} {
+		t.Run(policy, func(t *testing.T) {
+			c := NewInMemoryCache(config.CacheConfig{Policy: policy, MaxEntries: 3}, nopLogger())
+			repo := &fakeRepository{orders: []*domain.Order{testOrder("newest", 1), testOrder("middle", 1), testOrder("oldest", 1)}}
+
+			if err := c.RestoreFromDB(t.Context(), repo); err != nil {
+				t.Fatalf("RestoreFromDB() error = %v", err)
+			}
+			c.Set(t.Context(), testOrder("fresh", 1))
+
+			if got := cachedUIDs(t, c, "fresh", "newest", "middle", "oldest"); !slices.Equal(got, []string{"fresh", "newest", "middle"}) {
+				t.Fatalf("cached %v, want the oldest restored order evicted first", got)
+			}
+		})
+	}
+}
+
+func TestRestoreFromDBKeepsNewestWithinBudget(t *testing.T) {
+	c := NewInMemoryCache(config.CacheConfig{Policy: PolicyLRU, MaxEntries: 2}, nopLogger())
+	repo := &fakeRepository{orders: []*domain.Order{testOrder("newest", 1), testOrder("middle", 1), testOrder("oldest", 1)}}
+
+	if err := c.RestoreFromDB(t.Context(), repo); err != nil {
+		t.Fatalf("RestoreFromDB() error = %v", err)
+	}
+
+	if got := cachedUIDs(t, c, "newest", "middle", "oldest"); !slices.Equal(got, []string{"newest", "middle"}) {
+		t.Fatalf("cached %v, want the two newest orders", got)
+	}
+}
+
+func assertCachedVersion(t *testing.T, c ports.Cache, uid string, want int64) {
+	t.Helper()
+
+	order, found := c.Get(t.Context(), uid)
+	if !found {
+		t.Fatalf("order %s not cached", uid)
+	}
+	if order.Version != want {
+		t.Fatalf("order %s version = %d, want %d", uid, order.Version, want)
+	}
+}
+
+func TestSetKeepsNewerVersion(t *testing.T) {
+	c := NewInMemoryCache(config.CacheConfig{Policy: PolicyLRU}, nopLogger())
+
+	c.Set(t.Context(), testOrder("versioned", 5))
+	c.Set(t.Context(), testOrder("versioned", 4))
+	assertCachedVersion(t, c, "versioned", 5)
+
+	stale := testOrder("versioned", 5)
+	stale.TrackNumber = "REPLAYED"
+	c.Set(t.Context(), stale)
+	if order, _ := c.Get(t.Context(), "versioned"); order.TrackNumber == "REPLAYED" {
+		t.Fatal("a write with the cached version replaced the cached order")
+	}
+
+	c.Set(t.Context(), testOrder("versioned", 6))
+	assertCachedVersion(t, c, "versioned", 6)
+}
+
+func TestSetCompleteSkipsOutdatedResult(t *testing.T) {
+	c := NewInMemoryCache(config.CacheConfig{Policy: PolicyLRU}, nopLogger())
+	c.Set(t.Context(), testOrder("updated", 3))
+
+	c.SetComplete(t.Context(), domain.IndexCustomerID, "customer", []*domain.Order{testOrder("updated", 2)})
+
+	if _, complete := c.Find(t.Context(), domain.IndexCustomerID, "customer"); complete {
+		t.Fatal("key marked complete from a result older than the cached order")
+	}
+	assertCachedVersion(t, c, "updated", 3)
+}
+
+func TestRestoreFromDBReplacesStaleOrders(t *testing.T) {
+	c := NewInMemoryCache(config.CacheConfig{Policy: PolicyLRU}, nopLogger())
+	c.Set(t.Context(), testOrder("stale", 1))
+
+	if err := c.RestoreFromDB(t.Context(), &fakeRepository{orders: []*domain.Order{testOrder("stale", 2)}}); err != nil {
+		t.Fatalf("RestoreFromDB() error = %v", err)
+	}
+	assertCachedVersion(t, c, "stale", 2)
+}
+
+func TestInvalidateClearsCompleteKeysOfOtherReplicasWrites(t *testing.T) {
+	tests := []struct {
+		name   string
+		change domain.OrderChange
+		want   bool
+	}{
+		{
+			name:   "new order with the key",
+			change: domain.NewOrderChange(testOrder("written-elsewhere", 1)),
+			want:   false,
+		},
+		{
+			name:   "unknown keys",
+			change: domain.OrderChange{OrderUID: "written-elsewhere", Version: 1},
+			want:   false,
+		},
+		{
+			name: "other keys",
+			change: domain.OrderChange{OrderUID: "written-elsewhere", Version: 1, Keys: map[domain.OrderIndex][]string{
+				domain.IndexCustomerID: {"someone-else"},
+			}},
+			want: true,
+		},
+		{
+			name:   "own write",
+			change: domain.NewOrderChange(testOrder("cached", 1)),
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewInMemoryCache(config.CacheConfig{Policy: PolicyLRU}, nopLogger())
+			c.SetComplete(t.Context(), domain.IndexCustomerID, "customer", []*domain.Order{testOrder("cached", 1)})
+
+			c.Invalidate(t.Context(), tt.change)
+
+			if _, complete := c.Find(t.Context(), domain.IndexCustomerID, "customer"); complete != tt.want {
+				t.Fatalf("Find() complete = %v, want %v", complete, tt.want)
+			}
+		})
+	}
+}
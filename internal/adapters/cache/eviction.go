@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"cmp"
+	"container/heap"
+	"container/list"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+)
+
+const (
+	PolicyLRU = "lru"
+	PolicyLFU = "lfu"
+)
+
+type entry struct {
+	expiresAt time.Time
+	storedAt  time.Time
+	order     *domain.Order
+	element   *list.Element
+	key       string
+	size      int64
+	hits      uint64
+	touched   uint64
+	heapIndex int
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && now.After(e.expiresAt)
+}
+
+// evictionPolicy orders entries for eviction; callers hold the cache lock.
+type evictionPolicy interface {
+	add(e *entry)
+	touch(e *entry)
+	remove(e *entry)
+	victim() *entry
+	// ordered yields the entries from the first to the last eviction candidate.
+	ordered() iter.Seq[*entry]
+}
+
+func newEvictionPolicy(name string) evictionPolicy {
+	if name == PolicyLFU {
+		return &lfuPolicy{}
+	}
+	return &lruPolicy{order: list.New()}
+}
+
+type lruPolicy struct {
+	order *list.List
+}
+
+func (p *lruPolicy) add(e *entry) {
+	e.element = p.order.PushFront(e)
+}
+
+func (p *lruPolicy) touch(e *entry) {
+	p.order.MoveToFront(e.element)
+}
+
+func (p *lruPolicy) remove(e *entry) {
+	p.order.Remove(e.element)
+	e.element = nil
+}
+
+func (p *lruPolicy) ordered() iter.Seq[*entry] {
+	return func(yield func(*entry) bool) {
+		for element := p.order.Back(); element != nil; element = element.Prev() {
+			e, _ := element.Value.(*entry)
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+func (p *lruPolicy) victim() *entry {
+	back := p.order.Back()
+	if back == nil {
+		return nil
+	}
+	victim, _ := back.Value.(*entry)
+	return victim
+}
+
+// lfuPolicy evicts the least frequently used entry, the least recent on ties.
+type lfuPolicy struct {
+	entries lfuHeap
+	clock   uint64
+}
+
+func (p *lfuPolicy) add(e *entry) {
+	p.clock++
+	e.hits = 1
+	e.touched = p.clock
+	heap.Push(&p.entries, e)
+}
+
+func (p *lfuPolicy) touch(e *entry) {
+	p.clock++
+	e.hits++
+	e.touched = p.clock
+	heap.Fix(&p.entries, e.heapIndex)
+}
+
+func (p *lfuPolicy) remove(e *entry) {
+	heap.Remove(&p.entries, e.heapIndex)
+}
+
+func (p *lfuPolicy) ordered() iter.Seq[*entry] {
+	sorted := slices.Clone(p.entries)
+	slices.SortFunc(sorted, func(a, b *entry) int {
+		return cmp.Or(cmp.Compare(a.hits, b.hits), cmp.Compare(a.touched, b.touched))
+	})
+	return slices.Values(sorted)
+}
+
+func (p *lfuPolicy) victim() *entry {
+	if len(p.entries) == 0 {
+		return nil
+	}
+	return p.entries[0]
+}
+
+type lfuHeap []*entry
+
+func (h lfuHeap) Len() int { return len(h) }
+
+func (h lfuHeap) Less(i, j int) bool {
+	if h[i].hits != h[j].hits {
+		return h[i].hits < h[j].hits
+	}
+	return h[i].touched < h[j].touched
+}
+
+func (h lfuHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].heapIndex = i
+	h[j].heapIndex = j
+}
+
+func (h *lfuHeap) Push(x any) {
+	e, _ := x.(*entry)
+	e.heapIndex = len(*h)
+	*h = append(*h, e)
+}
+
+func (h *lfuHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	old[len(old)-1] = nil
+	last.heapIndex = -1
+	*h = old[:len(old)-1]
+	return last
+}
+
+// estimateSize approximates the memory held by an order, for budgeting.
+func estimateSize(order *domain.Order) int64 {
+	const (
+		orderOverhead    = 256
+		deliveryOverhead = 160
+		paymentOverhead  = 160
+		itemOverhead     = 160
+	)
+
+	size := int64(orderOverhead + len(order.Raw) + len(order.OrderUID) + len(order.TrackNumber) +
+		len(order.Entry) + len(order.Locale) + len(order.InternalSignature) + len(order.CustomerID) +
+		len(order.DeliveryService) + len(order.Shardkey) + len(order.OofShard))
+
+	if d := order.Delivery; d != nil {
+		size += int64(deliveryOverhead + len(d.Name) + len(d.Phone) + len(d.Zip) + len(d.City) +
+			len(d.Address) + len(d.Region) + len(d.Email))
+	}
+	if p := order.Payment; p != nil {
+		size += int64(paymentOverhead + len(p.Transaction) + len(p.RequestID) + len(p.Currency) +
+			len(p.Provider) + len(p.Bank))
+	}
+	for _, item := range order.Items {
+		size += int64(itemOverhead + len(item.Name) + len(item.Size) + len(item.Brand) +
+			len(item.TrackNumber) + len(item.RID))
+	}
+	return size
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"slices"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+)
+
+// secondaryIndex maps keys to cached orders; complete keys hold a full database result.
+type secondaryIndex struct {
+	name     domain.OrderIndex
+	members  map[string]map[string]struct{}
+	complete map[string]struct{}
+}
+
+func newSecondaryIndexes() map[domain.OrderIndex]*secondaryIndex {
+	indexes := make(map[domain.OrderIndex]*secondaryIndex, len(domain.OrderIndexes))
+	for _, name := range domain.OrderIndexes {
+		indexes[name] = &secondaryIndex{
+			name:     name,
+			members:  make(map[string]map[string]struct{}),
+			complete: make(map[string]struct{}),
+		}
+	}
+	return indexes
+}
+
+func (idx *secondaryIndex) add(order *domain.Order) {
+	for _, key := range order.IndexKeys(idx.name) {
+		uids, exists := idx.members[key]
+		if !exists {
+			uids = make(map[string]struct{}, 1)
+			idx.members[key] = uids
+		}
+		uids[order.OrderUID] = struct{}{}
+	}
+}
+
+func (idx *secondaryIndex) remove(order *domain.Order) {
+	for _, key := range order.IndexKeys(idx.name) {
+		idx.unlink(key, order.OrderUID, false)
+	}
+}
+
+// replace swaps in a newer version without marking the keys incomplete.
+func (idx *secondaryIndex) replace(previous, current *domain.Order) {
+	currentKeys := current.IndexKeys(idx.name)
+	for _, key := range previous.IndexKeys(idx.name) {
+		if !slices.Contains(currentKeys, key) {
+			idx.unlink(key, previous.OrderUID, true)
+		}
+	}
+	idx.add(current)
+}
+
+func (idx *secondaryIndex) unlink(key, orderUID string, keepComplete bool) {
+	uids := idx.members[key]
+	delete(uids, orderUID)
+	if len(uids) == 0 {
+		delete(idx.members, key)
+		delete(idx.complete, key)
+	} else if !keepComplete {
+		delete(idx.complete, key)
+	}
+}
+
+// lookup returns the sorted UIDs cached under key and whether they are complete.
+func (idx *secondaryIndex) lookup(key string) ([]string, bool) {
+	uids := idx.members[key]
+	if len(uids) == 0 {
+		return nil, false
+	}
+
+	_, complete := idx.complete[key]
+	result := make([]string, 0, len(uids))
+	for uid := range uids {
+		result = append(result, uid)
+	}
+	slices.Sort(result)
+	return result, complete || idx.name.Unique()
+}
+
+func (idx *secondaryIndex) unmark(keys ...string) {
+	for _, key := range keys {
+		delete(idx.complete, key)
+	}
+}
+
+func (idx *secondaryIndex) markComplete(key string) {
+	if _, exists := idx.members[key]; exists {
+		idx.complete[key] = struct{}{}
+	}
+}
@@ -0,0 +1,316 @@
+package cache
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrRedisAddrRequired = errors.New("cache.redis.addr is required for the redis and tiered backends")
+
+// cachedOrder adds back Raw and Version, which the order's JSON hides.
+type cachedOrder struct {
+	*domain.Order
+	Raw     json.RawMessage `json:"raw,omitempty"`
+	Version int64           `json:"version"`
+}
+
+// invalidateScript deletes the order unless it holds the announced version or newer.
+var invalidateScript = redis.NewScript(`
+local payload = redis.call('GET', KEYS[1])
+if not payload then
+  return 0
+end
+local ok, cached = pcall(cjson.decode, payload)
+if ok and tonumber(cached.version) and tonumber(cached.version) >= tonumber(ARGV[1]) then
+  return 0
+end
+return redis.call('DEL', KEYS[1])
+`)
+
+// setIfNewerScript writes the order unless the key holds the same or a newer version.
+var setIfNewerScript = redis.NewScript(`
+local payload = redis.call('GET', KEYS[1])
+if payload then
+  local ok, cached = pcall(cjson.decode, payload)
+  if ok and tonumber(cached.version) and tonumber(cached.version) >= tonumber(ARGV[2]) then
+    return 0
+  end
+end
+if tonumber(ARGV[3]) > 0 then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[3])
+else
+  redis.call('SET', KEYS[1], ARGV[1])
+end
+return 1
+`)
+
+type redisCache struct {
+	client redis.UniversalClient
+	log    logger.Logger
+	cfg    config.CacheConfig
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	errors atomic.Uint64
+}
+
+func NewRedisCache(cfg config.CacheConfig, log logger.Logger) (ports.Cache, error) {
+	cache, err := newRedisCache(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	return cache, nil
+}
+
+func newRedisCache(cfg config.CacheConfig, log logger.Logger) (*redisCache, error) {
+	if cfg.Redis.Addr == "" {
+		return nil, ErrRedisAddrRequired
+	}
+
+	opts := &redis.Options{
+		Addr:         cfg.Redis.Addr,
+		Username:     cfg.Redis.Username,
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+		DialTimeout:  cfg.Redis.DialTimeout,
+		ReadTimeout:  cfg.Redis.Timeout,
+		WriteTimeout: cfg.Redis.Timeout,
+		PoolSize:     cfg.Redis.PoolSize,
+	}
+	if cfg.Redis.TLS {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+
+	return &redisCache{
+		client: redis.NewClient(opts),
+		log:    log,
+		cfg:    cfg,
+	}, nil
+}
+
+func (c *redisCache) key(orderUID string) string {
+	return c.cfg.Redis.KeyPrefix + orderUID
+}
+
+func (c *redisCache) Get(ctx context.Context, orderUID string) (order *domain.Order, found bool) {
+	ctx, span := tracer.Start(ctx, "cache.redis.Get", trace.WithAttributes(attribute.String("order.uid", orderUID)))
+	defer func() {
+		span.SetAttributes(attribute.Bool("cache.hit", found))
+		span.End()
+	}()
+
+	if orderUID == "" {
+		c.log.WithContext(ctx).Warn("attempt to get order with empty orderUID")
+		return nil, false
+	}
+
+	payload, err := c.client.Get(ctx, c.key(orderUID)).Bytes()
+	if err != nil {
+		c.misses.Add(1)
+		if !errors.Is(err, redis.Nil) {
+			c.errors.Add(1)
+			c.log.WithContext(ctx).Warn("failed to read order from redis", "order_uid", orderUID, "error", err)
+		}
+		return nil, false
+	}
+
+	order, err = decodeCachedOrder(payload)
+	if err != nil {
+		c.misses.Add(1)
+		c.errors.Add(1)
+		c.log.WithContext(ctx).Error("invalid order in redis", "order_uid", orderUID, "error", err)
+		return nil, false
+	}
+
+	c.hits.Add(1)
+	c.log.WithContext(ctx).Debug("order retrieved from redis", "order_uid", orderUID)
+	return order, true
+}
+
+func (c *redisCache) Set(ctx context.Context, order *domain.Order) {
+	ctx, span := tracer.Start(ctx, "cache.redis.Set")
+	defer span.End()
+
+	if order == nil || order.OrderUID == "" || order.DateCreated.IsZero() {
+		c.log.WithContext(ctx).Warn("attempt to save invalid order")
+		return
+	}
+
+	payload, err := encodeCachedOrder(order)
+	if err != nil {
+		c.log.WithContext(ctx).Error("failed to encode order for redis", "order_uid", order.OrderUID, "error", err)
+		return
+	}
+
+	written, err := setIfNewerScript.Run(ctx, c.client, c.setKeys(order), c.setArgs(order, payload)...).Bool()
+	if err != nil {
+		c.errors.Add(1)
+		c.log.WithContext(ctx).Warn("failed to write order to redis", "order_uid", order.OrderUID, "error", err)
+		return
+	}
+	c.log.WithContext(ctx).Debug("order saved in redis", "order_uid", order.OrderUID, "version", order.Version, "written", written)
+}
+
+func (c *redisCache) setKeys(order *domain.Order) []string {
+	return []string{c.key(order.OrderUID)}
+}
+
+func (c *redisCache) setArgs(order *domain.Order, payload []byte) []any {
+	return []any{payload, order.Version, c.cfg.TTL.Milliseconds()}
+}
+
+// Find never answers from Redis; lookups by secondary keys go to Postgres.
+func (c *redisCache) Find(context.Context, domain.OrderIndex, string) ([]*domain.Order, bool) {
+	return nil, false
+}
+
+func (c *redisCache) SetComplete(ctx context.Context, _ domain.OrderIndex, _ string, orders []*domain.Order) {
+	for _, order := range orders {
+		c.Set(ctx, order)
+	}
+}
+
+func (c *redisCache) Invalidate(ctx context.Context, change domain.OrderChange) {
+	if err := invalidateScript.Run(ctx, c.client, []string{c.key(change.OrderUID)}, change.Version).Err(); err != nil {
+		c.errors.Add(1)
+		c.log.WithContext(ctx).Warn("failed to invalidate order in redis", "order_uid", change.OrderUID, "error", err)
+	}
+}
+
+// RestoreFromDB writes one pipeline per page; a failed warm-up keeps what was written.
+func (c *redisCache) RestoreFromDB(ctx context.Context, repo ports.OrderRepository) error {
+	if repo == nil {
+		return fmt.Errorf("repo nil: %w", ErrRepoNil)
+	}
+
+	warmup := c.cfg.Warmup
+	if warmup.Strategy == WarmupNone {
+		c.log.WithContext(ctx).Info("cache warm-up disabled")
+		return nil
+	}
+
+	orders, err := warmupOrders(ctx, warmup, repo, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := setIfNewerScript.Load(ctx, c.client).Err(); err != nil {
+		return fmt.Errorf("load redis set script: %w", err)
+	}
+
+	pageSize := max(warmup.PageSize, 1)
+	start := time.Now()
+	pipe := c.client.Pipeline()
+	restored, queued := 0, 0
+
+	flush := func() error {
+		if queued == 0 {
+			return nil
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return fmt.Errorf("redis pipeline: %w", err)
+		}
+		queued = 0
+		return nil
+	}
+
+	for order, err := range orders {
+		if err != nil {
+			c.log.WithContext(ctx).Error("failed to restore cache from DB", "strategy", warmup.Strategy, "restored", restored, "error", err)
+			return fmt.Errorf("restore cache from DB: %w", err)
+		}
+		if order == nil || order.OrderUID == "" || order.DateCreated.IsZero() {
+			continue
+		}
+
+		payload, err := encodeCachedOrder(order)
+		if err != nil {
+			c.log.WithContext(ctx).Warn("failed to encode order for redis, skipping", "order_uid", order.OrderUID, "error", err)
+			continue
+		}
+		setIfNewerScript.EvalSha(ctx, pipe, c.setKeys(order), c.setArgs(order, payload)...)
+		queued++
+		restored++
+
+		if queued >= pageSize {
+			if err := flush(); err != nil {
+				c.log.WithContext(ctx).Error("failed to write warm-up batch to redis", "restored", restored, "error", err)
+				return err
+			}
+		}
+		if restored%progressEvery == 0 {
+			c.log.WithContext(ctx).Info("cache restore in progress", "strategy", warmup.Strategy, "restored", restored, "elapsed", time.Since(start))
+		}
+	}
+
+	if err := flush(); err != nil {
+		c.log.WithContext(ctx).Error("failed to write warm-up batch to redis", "restored", restored, "error", err)
+		return err
+	}
+
+	c.log.WithContext(ctx).Info("cache restored from DB into redis", "strategy", warmup.Strategy, "orders_count", restored, "elapsed", time.Since(start))
+	return nil
+}
+
+func (c *redisCache) Stats() domain.CacheStats {
+	return domain.CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+	}
+}
+
+func (c *redisCache) HealthCheck(ctx context.Context) (map[string]any, error) {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("ping redis: %w", err)
+	}
+
+	stats := c.client.PoolStats()
+	return map[string]any{
+		"addr":          c.cfg.Redis.Addr,
+		"total_conns":   stats.TotalConns,
+		"idle_conns":    stats.IdleConns,
+		"errors_total":  c.errors.Load(),
+		"pool_timeouts": stats.Timeouts,
+	}, nil
+}
+
+func (c *redisCache) Close() error {
+	if err := c.client.Close(); err != nil {
+		return fmt.Errorf("close redis client: %w", err)
+	}
+	return nil
+}
+
+func encodeCachedOrder(order *domain.Order) ([]byte, error) {
+	wire := cachedOrder{Order: order, Version: order.Version}
+	if json.Valid(order.Raw) {
+		wire.Raw = order.Raw
+	}
+	payload, err := json.Marshal(wire)
+	if err != nil {
+		return nil, fmt.Errorf("marshal cached order: %w", err)
+	}
+	return payload, nil
+}
+
+func decodeCachedOrder(payload []byte) (*domain.Order, error) {
+	wire := cachedOrder{Order: &domain.Order{}}
+	if err := json.Unmarshal(payload, &wire); err != nil {
+		return nil, fmt.Errorf("unmarshal cached order: %w", err)
+	}
+	wire.Order.Raw = []byte(wire.Raw)
+	wire.Order.Version = wire.Version
+	return wire.Order, nil
+}
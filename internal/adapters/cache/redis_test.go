@@ -0,0 +1,133 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+)
+
+const testKeyPrefix = "order:"
+
+func newTestRedisCache(t *testing.T, ttl time.Duration) (*redisCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	cache, err := newRedisCache(config.CacheConfig{
+		Redis:  config.RedisConfig{Addr: server.Addr(), KeyPrefix: testKeyPrefix},
+		Warmup: config.CacheWarmupConfig{Strategy: WarmupRecent, PageSize: 2},
+		TTL:    ttl,
+	}, nopLogger())
+	if err != nil {
+		t.Fatalf("newRedisCache() error = %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+	return cache, server
+}
+
+func assertRedisVersion(t *testing.T, cache *redisCache, uid string, want int64) {
+	t.Helper()
+
+	order, found := cache.Get(t.Context(), uid)
+	if !found {
+		t.Fatalf("order %s not found in redis", uid)
+	}
+	if order.Version != want {
+		t.Fatalf("order %s version = %d, want %d", uid, order.Version, want)
+	}
+}
+
+func TestRedisSetRoundTrip(t *testing.T) {
+	cache, _ := newTestRedisCache(t, 0)
+	order := testOrder("roundtrip", 3)
+	order.Raw = []byte(`{"order_uid":"roundtrip"}`)
+
+	cache.Set(t.Context(), order)
+
+	got, found := cache.Get(t.Context(), "roundtrip")
+	if !found {
+		t.Fatal("order not found after Set")
+	}
+	if got.TrackNumber != order.TrackNumber || got.Version != 3 || string(got.Raw) != string(order.Raw) {
+		t.Fatalf("Get() = %+v, want %+v", got, order)
+	}
+	if _, found := cache.Get(t.Context(), "missing"); found {
+		t.Fatal("Get() found an order that was never set")
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("stats = %+v, want one hit and one miss", stats)
+	}
+}
+
+func TestRedisSetKeepsNewerVersion(t *testing.T) {
+	cache, _ := newTestRedisCache(t, 0)
+
+	cache.Set(t.Context(), testOrder("versioned", 5))
+	cache.Set(t.Context(), testOrder("versioned", 4))
+	assertRedisVersion(t, cache, "versioned", 5)
+
+	cache.Set(t.Context(), testOrder("versioned", 6))
+	assertRedisVersion(t, cache, "versioned", 6)
+}
+
+func TestRedisSetAppliesTTL(t *testing.T) {
+	cache, server := newTestRedisCache(t, time.Minute)
+
+	cache.Set(t.Context(), testOrder("expiring", 1))
+
+	if ttl := server.TTL(testKeyPrefix + "expiring"); ttl != time.Minute {
+		t.Fatalf("TTL = %v, want %v", ttl, time.Minute)
+	}
+	server.FastForward(time.Minute + time.Second)
+	if _, found := cache.Get(t.Context(), "expiring"); found {
+		t.Fatal("order still cached after its TTL")
+	}
+}
+
+func TestRedisInvalidate(t *testing.T) {
+	cache, _ := newTestRedisCache(t, 0)
+	cache.Set(t.Context(), testOrder("invalidated", 2))
+
+	cache.Invalidate(t.Context(), domain.OrderChange{OrderUID: "invalidated", Version: 2})
+	assertRedisVersion(t, cache, "invalidated", 2)
+
+	cache.Invalidate(t.Context(), domain.OrderChange{OrderUID: "invalidated", Version: 3})
+	if _, found := cache.Get(t.Context(), "invalidated"); found {
+		t.Fatal("order older than the announced version was not invalidated")
+	}
+}
+
+func TestRedisRestoreFromDB(t *testing.T) {
+	cache, server := newTestRedisCache(t, time.Hour)
+	cache.Set(t.Context(), testOrder("updated", 7))
+	cache.Set(t.Context(), testOrder("stale", 1))
+
+	repo := &fakeRepository{orders: []*domain.Order{
+		testOrder("fresh", 1), testOrder("updated", 6), testOrder("stale", 2), {OrderUID: "invalid"},
+	}}
+	if err := cache.RestoreFromDB(t.Context(), repo); err != nil {
+		t.Fatalf("RestoreFromDB() error = %v", err)
+	}
+
+	assertRedisVersion(t, cache, "fresh", 1)
+	assertRedisVersion(t, cache, "updated", 7)
+	assertRedisVersion(t, cache, "stale", 2)
+	if server.Exists(testKeyPrefix + "invalid") {
+		t.Fatal("order without date_created was restored")
+	}
+	if ttl := server.TTL(testKeyPrefix + "fresh"); ttl != time.Hour {
+		t.Fatalf("restored TTL = %v, want %v", ttl, time.Hour)
+	}
+}
+
+func TestRedisRestoreFromDBSurfacesWriteFailure(t *testing.T) {
+	cache, server := newTestRedisCache(t, 0)
+	server.Close()
+
+	repo := &fakeRepository{orders: []*domain.Order{testOrder("unreachable", 1)}}
+	if err := cache.RestoreFromDB(t.Context(), repo); err == nil {
+		t.Fatal("RestoreFromDB() succeeded with redis down")
+	}
+}
@@ -0,0 +1,299 @@
+package cache
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+)
+
+// A snapshot is gzip-compressed NDJSON: a header, one line per entry from the
+// first to the last eviction candidate, and a SHA-256 trailer.
+const (
+	snapshotFormat  = "order-cache-snapshot"
+	snapshotVersion = 2
+
+	// reconcileSkew absorbs clock differences between this host and Postgres.
+	reconcileSkew = time.Minute
+
+	maxSnapshotLine = 64 << 20
+)
+
+var (
+	ErrSnapshotInvalid   = errors.New("invalid cache snapshot")
+	ErrSnapshotTooOld    = errors.New("cache snapshot is too old")
+	ErrSnapshotNotFound  = errors.New("cache snapshot not found")
+	ErrSnapshotRestoring = errors.New("cache restore in progress, snapshot skipped")
+)
+
+type snapshotHeader struct {
+	CreatedAt time.Time `json:"created_at"`
+	Format    string    `json:"format"`
+	Policy    string    `json:"policy"`
+	Version   int       `json:"version"`
+	Orders    int       `json:"orders"`
+}
+
+// snapshotEntry keeps the expiry, so a restart does not extend the TTL.
+type snapshotEntry struct {
+	ExpiresAt time.Time       `json:"expires_at,omitzero"`
+	Order     json.RawMessage `json:"order"`
+}
+
+type snapshotTrailer struct {
+	SHA256 string `json:"sha256"`
+}
+
+// SaveSnapshot writes path atomically and skips the write during a restore.
+func (c *inMemoryCache) SaveSnapshot(ctx context.Context, path string) (err error) {
+	ctx, span := tracer.Start(ctx, "cache.SaveSnapshot")
+	defer span.End()
+
+	c.mu.Lock()
+	if c.restoring > 0 {
+		c.mu.Unlock()
+		return ErrSnapshotRestoring
+	}
+	entries := make([]entry, 0, len(c.store.entries))
+	for e := range c.store.policy.ordered() {
+		entries = append(entries, entry{order: e.order, expiresAt: e.expiresAt})
+	}
+	c.mu.Unlock()
+
+	start := c.now()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("create snapshot directory: %w", err)
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot file: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = temp.Close()
+			_ = os.Remove(temp.Name())
+		}
+	}()
+
+	if err := writeSnapshot(temp, snapshotHeader{
+		CreatedAt: start,
+		Format:    snapshotFormat,
+		Policy:    c.cfg.Policy,
+		Version:   snapshotVersion,
+		Orders:    len(entries),
+	}, entries); err != nil {
+		return err
+	}
+	if err := temp.Sync(); err != nil {
+		return fmt.Errorf("sync snapshot file: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("close snapshot file: %w", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("replace snapshot file: %w", err)
+	}
+
+	c.log.WithContext(ctx).Info("cache snapshot written", "path", path, "orders", len(entries), "elapsed", time.Since(start))
+	return nil
+}
+
+func writeSnapshot(file *os.File, header snapshotHeader, entries []entry) error {
+	compressed := gzip.NewWriter(file)
+	buffered := bufio.NewWriter(compressed)
+	hash := sha256.New()
+
+	if err := writeSnapshotLine(buffered, header); err != nil {
+		return err
+	}
+	for _, saved := range entries {
+		order, err := encodeCachedOrder(saved.order)
+		if err != nil {
+			return fmt.Errorf("snapshot order %s: %w", saved.order.OrderUID, err)
+		}
+		payload, err := json.Marshal(snapshotEntry{ExpiresAt: saved.expiresAt, Order: order})
+		if err != nil {
+			return fmt.Errorf("snapshot order %s: %w", saved.order.OrderUID, err)
+		}
+		payload = append(payload, '\n')
+		hash.Write(payload)
+		if _, err := buffered.Write(payload); err != nil {
+			return fmt.Errorf("write snapshot: %w", err)
+		}
+	}
+	if err := writeSnapshotLine(buffered, snapshotTrailer{SHA256: hex.EncodeToString(hash.Sum(nil))}); err != nil {
+		return err
+	}
+
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := compressed.Close(); err != nil {
+		return fmt.Errorf("compress snapshot: %w", err)
+	}
+	return nil
+}
+
+func writeSnapshotLine(w *bufio.Writer, value any) error {
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encode snapshot line: %w", err)
+	}
+	if _, err := w.Write(append(payload, '\n')); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot loads path and re-fetches the orders Postgres changed since.
+func (c *inMemoryCache) LoadSnapshot(ctx context.Context, path string, maxAge time.Duration, repo ports.OrderRepository) error {
+	ctx, span := tracer.Start(ctx, "cache.LoadSnapshot")
+	defer span.End()
+
+	if repo == nil {
+		return fmt.Errorf("repo nil: %w", ErrRepoNil)
+	}
+
+	c.beginRestore()
+	defer c.endRestore()
+
+	start := c.now()
+	staged := newStore(c.cfg)
+	header, err := c.readSnapshot(path, staged)
+	if err != nil {
+		return err
+	}
+	if maxAge > 0 && start.Sub(header.CreatedAt) > maxAge {
+		return fmt.Errorf("%w: written %s ago", ErrSnapshotTooOld, start.Sub(header.CreatedAt).Round(time.Second))
+	}
+
+	refreshed, err := c.reconcile(ctx, staged, header.CreatedAt.Add(-reconcileSkew), repo)
+	if err != nil {
+		return err
+	}
+
+	c.swapIn(staged, start)
+	c.log.WithContext(ctx).Info("cache restored from snapshot",
+		"path", path, "snapshot_age", start.Sub(header.CreatedAt).Round(time.Second),
+		"orders", header.Orders, "refreshed", refreshed, "cached", len(staged.entries), "elapsed", time.Since(start))
+	return nil
+}
+
+func (c *inMemoryCache) readSnapshot(path string, staged *store) (snapshotHeader, error) {
+	var header snapshotHeader
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return header, fmt.Errorf("%w: %s", ErrSnapshotNotFound, path)
+		}
+		return header, fmt.Errorf("open snapshot: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	decompressed, err := gzip.NewReader(file)
+	if err != nil {
+		return header, fmt.Errorf("%w: %w", ErrSnapshotInvalid, err)
+	}
+	defer func() { _ = decompressed.Close() }()
+
+	scanner := bufio.NewScanner(decompressed)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxSnapshotLine)
+	next := func() ([]byte, error) {
+		if scanner.Scan() {
+			return scanner.Bytes(), nil
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrSnapshotInvalid, err)
+		}
+		return nil, fmt.Errorf("%w: unexpected end of file", ErrSnapshotInvalid)
+	}
+
+	line, err := next()
+	if err != nil {
+		return header, err
+	}
+	if err := json.Unmarshal(line, &header); err != nil {
+		return header, fmt.Errorf("%w: header: %w", ErrSnapshotInvalid, err)
+	}
+	if header.Format != snapshotFormat || header.Version != snapshotVersion {
+		return header, fmt.Errorf("%w: unsupported format %q version %d", ErrSnapshotInvalid, header.Format, header.Version)
+	}
+
+	hash := sha256.New()
+	for range header.Orders {
+		if line, err = next(); err != nil {
+			return header, err
+		}
+		hash.Write(line)
+		hash.Write([]byte{'\n'})
+
+		var saved snapshotEntry
+		if err := json.Unmarshal(line, &saved); err != nil {
+			return header, fmt.Errorf("%w: %w", ErrSnapshotInvalid, err)
+		}
+		order, err := decodeCachedOrder(saved.Order)
+		if err != nil {
+			return header, fmt.Errorf("%w: %w", ErrSnapshotInvalid, err)
+		}
+		added, ok := c.newEntry(order)
+		if !ok {
+			continue
+		}
+		if !saved.ExpiresAt.IsZero() && !added.expiresAt.IsZero() && saved.ExpiresAt.Before(added.expiresAt) {
+			added.expiresAt = saved.ExpiresAt
+		}
+		if !added.expired(c.now()) {
+			staged.insert(added, c.now())
+		}
+	}
+
+	if line, err = next(); err != nil {
+		return header, err
+	}
+	var trailer snapshotTrailer
+	if err := json.Unmarshal(line, &trailer); err != nil {
+		return header, fmt.Errorf("%w: trailer: %w", ErrSnapshotInvalid, err)
+	}
+	if trailer.SHA256 != hex.EncodeToString(hash.Sum(nil)) {
+		return header, fmt.Errorf("%w: checksum mismatch", ErrSnapshotInvalid)
+	}
+	return header, nil
+}
+
+// reconcile replaces staged orders that Postgres holds a newer version of.
+func (c *inMemoryCache) reconcile(ctx context.Context, staged *store, since time.Time, repo ports.OrderRepository) (int, error) {
+	var stale []string
+	for change, err := range repo.IterateChangesSince(ctx, since) {
+		if err != nil {
+			return 0, fmt.Errorf("reconcile cache snapshot: %w", err)
+		}
+		if cached, exists := staged.entries[change.OrderUID]; exists && cached.order.Version < change.Version {
+			stale = append(stale, change.OrderUID)
+		}
+	}
+
+	pageSize := max(c.cfg.Warmup.PageSize, 1)
+	for uids := range slices.Chunk(stale, pageSize) {
+		orders, err := repo.GetOrders(ctx, uids)
+		if err != nil {
+			return 0, fmt.Errorf("reconcile cache snapshot: %w", err)
+		}
+		for _, order := range orders {
+			if added, ok := c.newEntry(order); ok {
+				staged.insert(added, c.now())
+			}
+		}
+	}
+	return len(stale), nil
+}
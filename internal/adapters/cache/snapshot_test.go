@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+)
+
+func newClockedCache(t *testing.T, ttl time.Duration, now *time.Time) *inMemoryCache {
+	t.Helper()
+
+	c, _ := NewInMemoryCache(config.CacheConfig{Policy: PolicyLRU, TTL: ttl}, nopLogger()).(*inMemoryCache)
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestSnapshotKeepsEntryExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	saved := newClockedCache(t, time.Hour, &now)
+	saved.Set(t.Context(), testOrder("early", 1))
+	now = now.Add(40 * time.Minute)
+	saved.Set(t.Context(), testOrder("late", 1))
+	if err := saved.SaveSnapshot(t.Context(), path); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	now = now.Add(10 * time.Minute)
+	loaded := newClockedCache(t, time.Hour, &now)
+	if err := loaded.LoadSnapshot(t.Context(), path, 0, &fakeRepository{}); err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+	if got := cachedUIDs(t, loaded, "early", "late"); len(got) != 2 {
+		t.Fatalf("cached %v right after loading, want both orders", got)
+	}
+
+	now = now.Add(15 * time.Minute)
+	if got := cachedUIDs(t, loaded, "early", "late"); len(got) != 1 || got[0] != "late" {
+		t.Fatalf("cached %v after the first order's TTL, want [late]", got)
+	}
+}
+
+func TestSnapshotSkipsExpiredEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	saved := newClockedCache(t, time.Minute, &now)
+	saved.Set(t.Context(), testOrder("expired", 1))
+	if err := saved.SaveSnapshot(t.Context(), path); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	loaded := newClockedCache(t, time.Minute, &now)
+	if err := loaded.LoadSnapshot(t.Context(), path, 0, &fakeRepository{}); err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+	if stats := loaded.Stats(); stats.Size != 0 {
+		t.Fatalf("loaded %d entries, want the expired one skipped", stats.Size)
+	}
+}
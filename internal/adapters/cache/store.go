@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"slices"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+)
+
+type store struct {
+	policy     evictionPolicy
+	entries    map[string]*entry
+	indexes    map[domain.OrderIndex]*secondaryIndex
+	bytes      int64
+	maxBytes   int64
+	maxEntries int
+}
+
+func newStore(cfg config.CacheConfig) *store {
+	return &store{
+		policy:     newEvictionPolicy(cfg.Policy),
+		entries:    make(map[string]*entry),
+		indexes:    newSecondaryIndexes(),
+		maxBytes:   cfg.MaxBytes,
+		maxEntries: cfg.MaxEntries,
+	}
+}
+
+// insert evicts in policy order until the new entry fits.
+func (s *store) insert(added *entry, now time.Time) (evicted, expired int) {
+	previous, replacing := s.entries[added.key]
+	if replacing {
+		s.drop(previous)
+	}
+
+	for len(s.entries) > 0 && s.overLimit(added.size) {
+		victim := s.policy.victim()
+		s.remove(victim)
+		if victim.expired(now) {
+			expired++
+		} else {
+			evicted++
+		}
+	}
+
+	s.entries[added.key] = added
+	s.bytes += added.size
+	s.policy.add(added)
+	for _, index := range s.indexes {
+		if replacing {
+			index.replace(previous.order, added.order)
+		} else {
+			index.add(added.order)
+		}
+	}
+	return evicted, expired
+}
+
+// rankFirstLast makes the first entry the last eviction candidate.
+func (s *store) rankFirstLast(ranked []*entry) {
+	for _, e := range slices.Backward(ranked) {
+		if s.entries[e.key] != e {
+			continue
+		}
+		s.policy.remove(e)
+		s.policy.add(e)
+	}
+}
+
+func (s *store) overLimit(incoming int64) bool {
+	if s.maxEntries > 0 && len(s.entries)+1 > s.maxEntries {
+		return true
+	}
+	return s.maxBytes > 0 && s.bytes+incoming > s.maxBytes
+}
+
+func (s *store) remove(e *entry) {
+	s.drop(e)
+	for _, index := range s.indexes {
+		index.remove(e.order)
+	}
+}
+
+// drop leaves the indexes to the caller.
+func (s *store) drop(e *entry) {
+	s.policy.remove(e)
+	delete(s.entries, e.key)
+	s.bytes -= e.size
+}
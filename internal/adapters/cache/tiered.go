@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+)
+
+// Cache backends selectable with cache.backend.
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+	BackendTiered = "tiered"
+)
+
+// tieredCache keeps an in-memory L1 with a short TTL in front of Redis.
+type tieredCache struct {
+	local  *inMemoryCache
+	remote *redisCache
+	log    logger.Logger
+}
+
+func NewTieredCache(cfg config.CacheConfig, log logger.Logger) (ports.Cache, error) {
+	remote, err := newRedisCache(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+
+	localCfg := cfg
+	localCfg.TTL = cfg.Redis.LocalTTL
+	local, _ := NewInMemoryCache(localCfg, log).(*inMemoryCache)
+
+	return &tieredCache{local: local, remote: remote, log: log}, nil
+}
+
+func (c *tieredCache) Get(ctx context.Context, orderUID string) (*domain.Order, bool) {
+	if order, found := c.local.Get(ctx, orderUID); found {
+		return order, true
+	}
+
+	order, found := c.remote.Get(ctx, orderUID)
+	if found {
+		c.local.Set(ctx, order)
+	}
+	return order, found
+}
+
+func (c *tieredCache) Set(ctx context.Context, order *domain.Order) {
+	c.remote.Set(ctx, order)
+	c.local.Set(ctx, order)
+}
+
+// Find is answered by the local tier, the only one that keeps secondary indexes.
+func (c *tieredCache) Find(ctx context.Context, index domain.OrderIndex, key string) ([]*domain.Order, bool) {
+	return c.local.Find(ctx, index, key)
+}
+
+func (c *tieredCache) SetComplete(ctx context.Context, index domain.OrderIndex, key string, orders []*domain.Order) {
+	c.remote.SetComplete(ctx, index, key, orders)
+	c.local.SetComplete(ctx, index, key, orders)
+}
+
+// RestoreFromDB only warms Redis; the local tier is emptied and fills on demand.
+func (c *tieredCache) RestoreFromDB(ctx context.Context, repo ports.OrderRepository) error {
+	err := c.remote.RestoreFromDB(ctx, repo)
+	c.local.reset()
+	return err
+}
+
+func (c *tieredCache) Invalidate(ctx context.Context, change domain.OrderChange) {
+	c.local.Invalidate(ctx, change)
+	c.remote.Invalidate(ctx, change)
+}
+
+func (c *tieredCache) Stats() domain.CacheStats {
+	local, remote := c.local.Stats(), c.remote.Stats()
+	local.Hits += remote.Hits
+	local.Misses = remote.Misses
+	return local
+}
+
+func (c *tieredCache) HealthCheck(ctx context.Context) (map[string]any, error) {
+	return c.remote.HealthCheck(ctx)
+}
+
+func (c *tieredCache) Close() error {
+	return c.remote.Close()
+}
+
+func NewCache(cfg config.CacheConfig, log logger.Logger) (ports.Cache, error) {
+	switch cfg.Backend {
+	case BackendMemory, "":
+		return NewInMemoryCache(cfg, log), nil
+	case BackendRedis:
+		return NewRedisCache(cfg, log)
+	case BackendTiered:
+		return NewTieredCache(cfg, log)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownBackend, cfg.Backend)
+	}
+}
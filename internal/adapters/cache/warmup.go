@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+)
+
+// Warm-up strategies for RestoreFromDB.
+const (
+	WarmupRecent  = "recent"
+	WarmupWindow  = "window"
+	WarmupAll     = "all"
+	WarmupHotList = "hotlist"
+	WarmupNone    = "none"
+)
+
+const progressEvery = 1000
+
+var (
+	ErrUnknownWarmupStrategy = errors.New("unknown cache warm-up strategy")
+	ErrHotListPathRequired   = errors.New("cache.warmup.hot_list_path is required for the hotlist strategy")
+)
+
+// RestoreFromDB fills a fresh store and swaps it in once the warm-up succeeded;
+// the none strategy empties the cache instead.
+func (c *inMemoryCache) RestoreFromDB(ctx context.Context, repo ports.OrderRepository) error {
+	if repo == nil {
+		return fmt.Errorf("repo nil: %w", ErrRepoNil)
+	}
+
+	warmup := c.cfg.Warmup
+	if warmup.Strategy == WarmupNone {
+		c.reset()
+		c.log.WithContext(ctx).Info("cache warm-up disabled")
+		return nil
+	}
+
+	orders, err := warmupOrders(ctx, warmup, repo, c.now())
+	if err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("context canceled before query: %w", ErrContextCanceled)
+	}
+
+	c.beginRestore()
+	defer c.endRestore()
+
+	start := c.now()
+	staged := newStore(c.cfg)
+	restored := 0
+	var ranked []*entry
+
+	for order, err := range orders {
+		if err != nil {
+			c.log.WithContext(ctx).Error("failed to restore cache from DB, keeping current contents",
+				"strategy", warmup.Strategy, "restored", restored, "error", err)
+			return fmt.Errorf("restore cache from DB: %w", err)
+		}
+
+		if err := ctx.Err(); err != nil {
+			c.log.WithContext(ctx).Warn("context canceled during cache restore, keeping current contents", "processed_orders", restored)
+			return fmt.Errorf("context canceled during restore: %w", ErrContextCanceled)
+		}
+
+		if order == nil || order.OrderUID == "" || order.DateCreated.IsZero() {
+			continue
+		}
+		added, ok := c.newEntry(order)
+		if ok {
+			// Past the budget, the rest would only push out better orders.
+			if staged.overLimit(added.size) {
+				break
+			}
+			added.storedAt = start
+			staged.insert(added, c.now())
+			ranked = append(ranked, added)
+		}
+
+		restored++
+		if restored%progressEvery == 0 {
+			c.log.WithContext(ctx).Info("cache restore in progress", "strategy", warmup.Strategy, "restored", restored, "elapsed", time.Since(start))
+		}
+	}
+
+	staged.rankFirstLast(ranked)
+	c.swapIn(staged, start)
+
+	c.log.WithContext(ctx).Info("cache restored from DB",
+		"strategy", warmup.Strategy, "orders_count", restored, "cached", len(staged.entries), "elapsed", time.Since(start))
+	return nil
+}
+
+// swapIn applies pending invalidations to staged and makes it the current store.
+func (c *inMemoryCache) swapIn(staged *store, start time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for orderUID, version := range c.pending {
+		invalidate(staged, domain.OrderChange{OrderUID: orderUID, Version: version})
+	}
+	for _, current := range c.store.entries {
+		if current.storedAt.After(start) {
+			carried := *current
+			carried.element, carried.hits, carried.touched = nil, 0, 0
+			c.insertLocked(staged, &carried)
+		}
+	}
+	c.store = staged
+}
+
+func (c *inMemoryCache) beginRestore() {
+	c.mu.Lock()
+	if c.restoring == 0 {
+		c.pending = make(map[string]int64)
+	}
+	c.restoring++
+	c.mu.Unlock()
+}
+
+func (c *inMemoryCache) endRestore() {
+	c.mu.Lock()
+	c.restoring--
+	if c.restoring == 0 {
+		c.pending = nil
+	}
+	c.mu.Unlock()
+}
+
+func warmupOrders(ctx context.Context, cfg config.CacheWarmupConfig, repo ports.OrderRepository, now time.Time) (iter.Seq2[*domain.Order, error], error) {
+	switch cfg.Strategy {
+	case WarmupRecent, "":
+		return repo.IterateRecent(ctx, cfg.Limit, cfg.PageSize), nil
+	case WarmupWindow:
+		return repo.IterateSince(ctx, now.Add(-cfg.Window), 0, cfg.PageSize), nil
+	case WarmupAll:
+		return repo.IterateRecent(ctx, 0, cfg.PageSize), nil
+	case WarmupHotList:
+		if cfg.HotListPath == "" {
+			return nil, ErrHotListPathRequired
+		}
+		uids, err := readHotList(cfg.HotListPath)
+		if err != nil {
+			return nil, err
+		}
+		return hotListOrders(ctx, repo, uids, cfg.PageSize), nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWarmupStrategy, cfg.Strategy)
+	}
+}
+
+// readHotList skips blank lines, # comments and duplicate UIDs.
+func readHotList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open hot list: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var (
+		uids []string
+		seen = make(map[string]struct{})
+	)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		uid := strings.TrimSpace(scanner.Text())
+		if uid == "" || strings.HasPrefix(uid, "#") {
+			continue
+		}
+		if _, dup := seen[uid]; dup {
+			continue
+		}
+		seen[uid] = struct{}{}
+		uids = append(uids, uid)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read hot list: %w", err)
+	}
+	return uids, nil
+}
+
+func hotListOrders(ctx context.Context, repo ports.OrderRepository, uids []string, pageSize int) iter.Seq2[*domain.Order, error] {
+	if pageSize <= 0 {
+		pageSize = len(uids)
+	}
+
+	return func(yield func(*domain.Order, error) bool) {
+		for chunk := range slices.Chunk(uids, max(pageSize, 1)) {
+			orders, err := repo.GetOrders(ctx, chunk)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for _, order := range orders {
+				if !yield(order, nil) {
+					return
+				}
+			}
+		}
+	}
+}
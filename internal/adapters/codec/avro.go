@@ -0,0 +1,72 @@
+package codec
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/hamba/avro/v2"
+)
+
+type avroDecoder struct {
+	registry ports.SchemaRegistry
+	api      avro.API
+	schemas  map[int]avro.Schema
+	mu       sync.RWMutex
+}
+
+func newAvroDecoder(registry ports.SchemaRegistry) *avroDecoder {
+	return &avroDecoder{
+		registry: registry,
+		api:      avro.Config{TagKey: "json"}.Freeze(),
+		schemas:  make(map[int]avro.Schema),
+	}
+}
+
+func (d *avroDecoder) decode(ctx context.Context, payload []byte) (*domain.Order, error) {
+	schemaID, body, framed := splitWireFormat(payload)
+	if !framed {
+		return nil, fmt.Errorf("%w: avro", ErrMissingSchemaID)
+	}
+
+	schema, err := d.schema(ctx, schemaID)
+	if err != nil {
+		return nil, err
+	}
+
+	var order domain.Order
+	if err := d.api.Unmarshal(schema, body, &order); err != nil {
+		return nil, fmt.Errorf("%w: avro: %w", ErrMalformedPayload, err)
+	}
+	return &order, nil
+}
+
+func (d *avroDecoder) schema(ctx context.Context, schemaID int) (avro.Schema, error) {
+	d.mu.RLock()
+	schema, cached := d.schemas[schemaID]
+	d.mu.RUnlock()
+	if cached {
+		return schema, nil
+	}
+
+	if d.registry == nil {
+		return nil, ErrRegistryNotConfigured
+	}
+
+	definition, err := d.registry.Schema(ctx, schemaID)
+	if err != nil {
+		return nil, fmt.Errorf("avro schema %d: %w", schemaID, err)
+	}
+
+	schema, err = avro.Parse(definition)
+	if err != nil {
+		return nil, fmt.Errorf("%w: avro schema %d: %w", ErrMalformedPayload, schemaID, err)
+	}
+
+	d.mu.Lock()
+	d.schemas[schemaID] = schema
+	d.mu.Unlock()
+	return schema, nil
+}
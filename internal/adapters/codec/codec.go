@@ -0,0 +1,125 @@
+package codec
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf"
+	FormatAvro     = "avro"
+
+	confluentMagicByte  = 0
+	confluentHeaderSize = 5
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported payload format")
+	ErrMalformedPayload  = errors.New("malformed payload")
+	ErrInvalidJSON       = errors.New("invalid JSON payload")
+	ErrMissingSchemaID   = errors.New("payload is not in schema registry wire format")
+)
+
+var contentTypeFormats = map[string]string{
+	FormatJSON:                           FormatJSON,
+	"application/json":                   FormatJSON,
+	"text/json":                          FormatJSON,
+	FormatProtobuf:                       FormatProtobuf,
+	"proto":                              FormatProtobuf,
+	"application/protobuf":               FormatProtobuf,
+	"application/x-protobuf":             FormatProtobuf,
+	"application/vnd.google.protobuf":    FormatProtobuf,
+	FormatAvro:                           FormatAvro,
+	"avro/binary":                        FormatAvro,
+	"application/avro":                   FormatAvro,
+	"application/vnd.apache.avro+binary": FormatAvro,
+}
+
+type formatDecoder interface {
+	decode(ctx context.Context, payload []byte) (*domain.Order, error)
+}
+
+type decoder struct {
+	formats       map[string]formatDecoder
+	log           logger.Logger
+	defaultFormat string
+}
+
+func NewDecoder(cfg config.CodecConfig, registry ports.SchemaRegistry, log logger.Logger) (ports.OrderDecoder, error) {
+	defaultFormat, err := ResolveFormat(cfg.DefaultFormat)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decoder{
+		formats: map[string]formatDecoder{
+			FormatJSON:     jsonDecoder{},
+			FormatProtobuf: protobufDecoder{},
+			FormatAvro:     newAvroDecoder(registry),
+		},
+		log:           log,
+		defaultFormat: defaultFormat,
+	}, nil
+}
+
+func ResolveFormat(contentType string) (string, error) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	if mediaType == "" {
+		return FormatJSON, nil
+	}
+
+	format, known := contentTypeFormats[mediaType]
+	if !known {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedFormat, contentType)
+	}
+	return format, nil
+}
+
+func (d *decoder) Decode(ctx context.Context, contentType string, payload []byte) (*domain.Order, error) {
+	format := d.defaultFormat
+	if contentType != "" {
+		resolved, err := ResolveFormat(contentType)
+		if err != nil {
+			d.log.Warn("unsupported payload content type", "content_type", contentType)
+			return nil, domain.NewRejectedError(domain.ReasonUnsupportedFormat, err)
+		}
+		format = resolved
+	}
+
+	order, err := d.formats[format].decode(ctx, payload)
+	if err != nil {
+		d.log.Warn("failed to decode payload", "format", format, "payload_size", len(payload), "error", err)
+		return nil, classifyDecodeError(format, err)
+	}
+	return order, nil
+}
+
+func classifyDecodeError(format string, err error) error {
+	switch {
+	case errors.Is(err, ErrSchemaNotFound):
+		return domain.NewRejectedError(domain.ReasonUnknownSchema, err)
+	case errors.Is(err, ErrRegistryUnavailable), errors.Is(err, ErrRegistryNotConfigured):
+		return domain.NewFailedError(domain.ReasonSchemaUnavailable, err)
+	case format == FormatJSON:
+		return domain.NewRejectedError(domain.ReasonInvalidJSON, err)
+	default:
+		return domain.NewRejectedError(domain.ReasonInvalidPayload, err)
+	}
+}
+
+func splitWireFormat(payload []byte) (int, []byte, bool) {
+	if len(payload) < confluentHeaderSize || payload[0] != confluentMagicByte {
+		return 0, payload, false
+	}
+	return int(binary.BigEndian.Uint32(payload[1:confluentHeaderSize])), payload[confluentHeaderSize:], true
+}
@@ -0,0 +1,333 @@
+package codec
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/hamba/avro/v2"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	testRegistryURL = fileRegistryScheme + "../../../schemas/registry"
+	testSchemaID    = 1
+)
+
+var testDateCreated = time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+
+func nopLogger() logger.Logger {
+	zapLogger := zap.NewNop()
+	return &logger.ZapLogger{Logger: zapLogger, Sugared: zapLogger.Sugar()}
+}
+
+func newTestDecoder(t *testing.T, registryURL string) ports.OrderDecoder {
+	t.Helper()
+
+	cfg := config.CodecConfig{SchemaRegistry: config.SchemaRegistryConfig{URL: registryURL}}
+	decoder, err := NewDecoder(cfg, NewSchemaRegistry(cfg.SchemaRegistry), nopLogger())
+	if err != nil {
+		t.Fatalf("NewDecoder() error = %v", err)
+	}
+	return decoder
+}
+
+func testOrder() *domain.Order {
+	return &domain.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: &domain.Delivery{
+			Name:    "Test Testov",
+			Phone:   "+9720000000",
+			Zip:     "2639809",
+			City:    "Kiryat Mozkin",
+			Address: "Ploshad Mira 15",
+			Region:  "Kraiot",
+			Email:   "test@gmail.com",
+		},
+		Payment: &domain.Payment{
+			Transaction:  "b563feb7b2b84b6test",
+			Currency:     "USD",
+			Provider:     "wbpay",
+			Amount:       1817,
+			PaymentDt:    1637907727,
+			Bank:         "alpha",
+			DeliveryCost: 1500,
+			GoodsTotal:   317,
+		},
+		Items: []domain.Item{{
+			ChrtID:      9934930,
+			TrackNumber: "WBILMTESTTRACK",
+			Price:       453,
+			RID:         "ab4219087a764ae0btest",
+			Name:        "Mascaras",
+			Sale:        30,
+			Size:        "0",
+			TotalPrice:  317,
+			NmID:        2389212,
+			Brand:       "Vivienne Sabo",
+			Status:      202,
+		}},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmID:            99,
+		DateCreated:     testDateCreated,
+		OofShard:        "1",
+	}
+}
+
+func assertOrder(t *testing.T, got *domain.Order) {
+	t.Helper()
+
+	want := testOrder()
+	if got.OrderUID != want.OrderUID || got.TrackNumber != want.TrackNumber || got.CustomerID != want.CustomerID ||
+		got.SmID != want.SmID || got.OofShard != want.OofShard || !got.DateCreated.Equal(want.DateCreated) {
+		t.Fatalf("decoded order = %+v, want %+v", got, want)
+	}
+	if got.Delivery == nil || *got.Delivery != *want.Delivery {
+		t.Fatalf("decoded delivery = %+v, want %+v", got.Delivery, want.Delivery)
+	}
+	if got.Payment == nil || *got.Payment != *want.Payment {
+		t.Fatalf("decoded payment = %+v, want %+v", got.Payment, want.Payment)
+	}
+	if len(got.Items) != 1 || got.Items[0] != want.Items[0] {
+		t.Fatalf("decoded items = %+v, want %+v", got.Items, want.Items)
+	}
+}
+
+func assertReason(t *testing.T, err error, want domain.FailureReason) {
+	t.Helper()
+
+	procErr, ok := domain.AsProcessingError(err)
+	if !ok {
+		t.Fatalf("error = %v, want a processing error", err)
+	}
+	if procErr.Reason != want {
+		t.Fatalf("reason = %q, want %q (error: %v)", procErr.Reason, want, err)
+	}
+}
+
+func confluentFrame(schemaID int, body []byte) []byte {
+	frame := make([]byte, confluentHeaderSize, confluentHeaderSize+len(body))
+	frame[0] = confluentMagicByte
+	binary.BigEndian.PutUint32(frame[1:], uint32(schemaID))
+	return append(frame, body...)
+}
+
+func encodeAvro(t *testing.T, order *domain.Order) []byte {
+	t.Helper()
+
+	definition, err := NewSchemaRegistry(config.SchemaRegistryConfig{URL: testRegistryURL}).Schema(t.Context(), testSchemaID)
+	if err != nil {
+		t.Fatalf("load schema: %v", err)
+	}
+	schema, err := avro.Parse(definition)
+	if err != nil {
+		t.Fatalf("parse schema: %v", err)
+	}
+	body, err := avro.Config{TagKey: "json"}.Freeze().Marshal(schema, order)
+	if err != nil {
+		t.Fatalf("encode avro: %v", err)
+	}
+	return confluentFrame(testSchemaID, body)
+}
+
+func appendProtoString(b []byte, num protowire.Number, value string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendProtoInt(b []byte, num protowire.Number, value int64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(value))
+}
+
+func appendProtoDouble(b []byte, num protowire.Number, value float64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, math.Float64bits(value))
+}
+
+func appendProtoMessage(b []byte, num protowire.Number, msg []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, msg)
+}
+
+func encodeProto(order *domain.Order) []byte {
+	var delivery []byte
+	delivery = appendProtoString(delivery, 1, order.Delivery.Name)
+	delivery = appendProtoString(delivery, 2, order.Delivery.Phone)
+	delivery = appendProtoString(delivery, 3, order.Delivery.Zip)
+	delivery = appendProtoString(delivery, 4, order.Delivery.City)
+	delivery = appendProtoString(delivery, 5, order.Delivery.Address)
+	delivery = appendProtoString(delivery, 6, order.Delivery.Region)
+	delivery = appendProtoString(delivery, 7, order.Delivery.Email)
+
+	var payment []byte
+	payment = appendProtoString(payment, 1, order.Payment.Transaction)
+	payment = appendProtoString(payment, 3, order.Payment.Currency)
+	payment = appendProtoString(payment, 4, order.Payment.Provider)
+	payment = appendProtoDouble(payment, 5, order.Payment.Amount)
+	payment = appendProtoInt(payment, 6, order.Payment.PaymentDt)
+	payment = appendProtoString(payment, 7, order.Payment.Bank)
+	payment = appendProtoDouble(payment, 8, order.Payment.DeliveryCost)
+	payment = appendProtoDouble(payment, 9, order.Payment.GoodsTotal)
+
+	msg := appendProtoString(nil, 1, order.OrderUID)
+	msg = appendProtoString(msg, 2, order.TrackNumber)
+	msg = appendProtoString(msg, 3, order.Entry)
+	msg = appendProtoMessage(msg, 4, delivery)
+	msg = appendProtoMessage(msg, 5, payment)
+	for _, item := range order.Items {
+		var encoded []byte
+		encoded = appendProtoInt(encoded, 1, item.ChrtID)
+		encoded = appendProtoString(encoded, 2, item.TrackNumber)
+		encoded = appendProtoDouble(encoded, 3, item.Price)
+		encoded = appendProtoString(encoded, 4, item.RID)
+		encoded = appendProtoString(encoded, 5, item.Name)
+		encoded = appendProtoInt(encoded, 6, int64(item.Sale))
+		encoded = appendProtoString(encoded, 7, item.Size)
+		encoded = appendProtoDouble(encoded, 8, item.TotalPrice)
+		encoded = appendProtoInt(encoded, 9, item.NmID)
+		encoded = appendProtoString(encoded, 10, item.Brand)
+		encoded = appendProtoInt(encoded, 11, int64(item.Status))
+		msg = appendProtoMessage(msg, 6, encoded)
+	}
+	msg = appendProtoString(msg, 7, order.Locale)
+	msg = appendProtoString(msg, 9, order.CustomerID)
+	msg = appendProtoString(msg, 10, order.DeliveryService)
+	msg = appendProtoString(msg, 11, order.Shardkey)
+	msg = appendProtoInt(msg, 12, int64(order.SmID))
+	msg = appendProtoMessage(msg, 13, appendProtoInt(nil, 1, order.DateCreated.Unix()))
+	return appendProtoString(msg, 14, order.OofShard)
+}
+
+func TestResolveFormat(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        string
+		wantErr     bool
+	}{
+		{contentType: "", want: FormatJSON},
+		{contentType: "application/json; charset=utf-8", want: FormatJSON},
+		{contentType: "application/x-protobuf", want: FormatProtobuf},
+		{contentType: "AVRO/BINARY", want: FormatAvro},
+		{contentType: "text/plain", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			got, err := ResolveFormat(tt.contentType)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupportedFormat) {
+					t.Fatalf("ResolveFormat() error = %v, want ErrUnsupportedFormat", err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("ResolveFormat() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	decoder := newTestDecoder(t, "")
+	payload := []byte(`{
+		"order_uid": "b563feb7b2b84b6test", "track_number": "WBILMTESTTRACK", "entry": "WBIL",
+		"delivery": {"name": "Test Testov", "phone": "+9720000000", "zip": "2639809", "city": "Kiryat Mozkin",
+			"address": "Ploshad Mira 15", "region": "Kraiot", "email": "test@gmail.com"},
+		"payment": {"transaction": "b563feb7b2b84b6test", "request_id": "", "currency": "USD", "provider": "wbpay",
+			"amount": 1817, "payment_dt": 1637907727, "bank": "alpha", "delivery_cost": 1500, "goods_total": 317, "custom_fee": 0},
+		"items": [{"chrt_id": 9934930, "track_number": "WBILMTESTTRACK", "price": 453, "rid": "ab4219087a764ae0btest",
+			"name": "Mascaras", "sale": 30, "size": "0", "total_price": 317, "nm_id": 2389212, "brand": "Vivienne Sabo", "status": 202}],
+		"locale": "en", "internal_signature": "", "customer_id": "test", "delivery_service": "meest",
+		"shardkey": "9", "sm_id": 99, "date_created": "2021-11-26T06:22:19Z", "oof_shard": "1"
+	}`)
+
+	order, err := decoder.Decode(t.Context(), "application/json", payload)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	assertOrder(t, order)
+
+	_, err = decoder.Decode(t.Context(), "", []byte(`{"order_uid":`))
+	assertReason(t, err, domain.ReasonInvalidJSON)
+}
+
+func TestDecodeProtobuf(t *testing.T) {
+	decoder := newTestDecoder(t, "")
+	body := encodeProto(testOrder())
+
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{name: "plain", payload: body},
+		{name: "confluent_framed", payload: confluentFrame(testSchemaID, append([]byte{0}, body...))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := decoder.Decode(t.Context(), "application/x-protobuf", tt.payload)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			assertOrder(t, order)
+		})
+	}
+
+	_, err := decoder.Decode(t.Context(), "application/x-protobuf", []byte{0x0a, 0xff})
+	assertReason(t, err, domain.ReasonInvalidPayload)
+}
+
+func TestDecodeAvro(t *testing.T) {
+	decoder := newTestDecoder(t, testRegistryURL)
+	payload := encodeAvro(t, testOrder())
+
+	order, err := decoder.Decode(t.Context(), "avro/binary", payload)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	assertOrder(t, order)
+
+	_, err = decoder.Decode(t.Context(), "avro/binary", payload[confluentHeaderSize:])
+	assertReason(t, err, domain.ReasonInvalidPayload)
+	if !errors.Is(err, ErrMissingSchemaID) {
+		t.Fatalf("unframed payload error = %v, want ErrMissingSchemaID", err)
+	}
+
+	_, err = decoder.Decode(t.Context(), "avro/binary", confluentFrame(99, payload[confluentHeaderSize:]))
+	assertReason(t, err, domain.ReasonUnknownSchema)
+
+	_, err = decoder.Decode(t.Context(), "avro/binary", confluentFrame(testSchemaID, []byte{0x01}))
+	assertReason(t, err, domain.ReasonInvalidPayload)
+}
+
+func TestDecodeAvroWithoutRegistry(t *testing.T) {
+	decoder := newTestDecoder(t, "")
+
+	_, err := decoder.Decode(t.Context(), "avro/binary", confluentFrame(testSchemaID, nil))
+	assertReason(t, err, domain.ReasonSchemaUnavailable)
+}
+
+func TestFileRegistrySchema(t *testing.T) {
+	registry := NewSchemaRegistry(config.SchemaRegistryConfig{URL: testRegistryURL})
+
+	schema, err := registry.Schema(t.Context(), testSchemaID)
+	if err != nil || schema == "" {
+		t.Fatalf("Schema(%d) = %q, %v, want the order schema", testSchemaID, schema, err)
+	}
+	if _, err := registry.Schema(t.Context(), 99); !errors.Is(err, ErrSchemaNotFound) {
+		t.Fatalf("Schema(99) error = %v, want ErrSchemaNotFound", err)
+	}
+}
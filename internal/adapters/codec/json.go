@@ -0,0 +1,19 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+)
+
+type jsonDecoder struct{}
+
+func (jsonDecoder) decode(_ context.Context, payload []byte) (*domain.Order, error) {
+	var order domain.Order
+	if err := json.Unmarshal(payload, &order); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidJSON, err)
+	}
+	return &order, nil
+}
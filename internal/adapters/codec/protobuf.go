@@ -0,0 +1,263 @@
+package codec
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field numbers follow schemas/order.proto.
+type protobufDecoder struct{}
+
+type protoField struct {
+	bytes  []byte
+	number protowire.Number
+	typ    protowire.Type
+	value  uint64
+}
+
+func (f protoField) string() string {
+	return string(f.bytes)
+}
+
+func (f protoField) int() int64 {
+	return int64(f.value)
+}
+
+func (f protoField) double() float64 {
+	return math.Float64frombits(f.value)
+}
+
+func (protobufDecoder) decode(_ context.Context, payload []byte) (*domain.Order, error) {
+	if _, body, framed := splitWireFormat(payload); framed {
+		indexes, err := skipMessageIndexes(body)
+		if err != nil {
+			return nil, err
+		}
+		payload = indexes
+	}
+
+	order, err := decodeProtoOrder(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: protobuf: %w", ErrMalformedPayload, err)
+	}
+	return order, nil
+}
+
+func skipMessageIndexes(body []byte) ([]byte, error) {
+	count, n := protowire.ConsumeVarint(body)
+	if n < 0 {
+		return nil, fmt.Errorf("%w: protobuf message indexes: %w", ErrMalformedPayload, protowire.ParseError(n))
+	}
+	body = body[n:]
+
+	for range protowire.DecodeZigZag(count) {
+		_, n = protowire.ConsumeVarint(body)
+		if n < 0 {
+			return nil, fmt.Errorf("%w: protobuf message indexes: %w", ErrMalformedPayload, protowire.ParseError(n))
+		}
+		body = body[n:]
+	}
+	return body, nil
+}
+
+func walkProto(data []byte, visit func(field protoField) error) error {
+	for len(data) > 0 {
+		number, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		field := protoField{number: number, typ: typ}
+		switch typ {
+		case protowire.VarintType:
+			field.value, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed64Type:
+			field.value, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			field.bytes, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(number, typ, data)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		data = data[n:]
+
+		if err := visit(field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeProtoOrder(data []byte) (*domain.Order, error) {
+	var order domain.Order
+	err := walkProto(data, func(field protoField) error {
+		switch field.number {
+		case 1:
+			order.OrderUID = field.string()
+		case 2:
+			order.TrackNumber = field.string()
+		case 3:
+			order.Entry = field.string()
+		case 4:
+			delivery, err := decodeProtoDelivery(field.bytes)
+			if err != nil {
+				return fmt.Errorf("delivery: %w", err)
+			}
+			order.Delivery = delivery
+		case 5:
+			payment, err := decodeProtoPayment(field.bytes)
+			if err != nil {
+				return fmt.Errorf("payment: %w", err)
+			}
+			order.Payment = payment
+		case 6:
+			item, err := decodeProtoItem(field.bytes)
+			if err != nil {
+				return fmt.Errorf("item: %w", err)
+			}
+			order.Items = append(order.Items, item)
+		case 7:
+			order.Locale = field.string()
+		case 8:
+			order.InternalSignature = field.string()
+		case 9:
+			order.CustomerID = field.string()
+		case 10:
+			order.DeliveryService = field.string()
+		case 11:
+			order.Shardkey = field.string()
+		case 12:
+			order.SmID = int(field.int())
+		case 13:
+			created, err := decodeProtoTimestamp(field.bytes)
+			if err != nil {
+				return fmt.Errorf("date_created: %w", err)
+			}
+			order.DateCreated = created
+		case 14:
+			order.OofShard = field.string()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func decodeProtoDelivery(data []byte) (*domain.Delivery, error) {
+	var delivery domain.Delivery
+	err := walkProto(data, func(field protoField) error {
+		switch field.number {
+		case 1:
+			delivery.Name = field.string()
+		case 2:
+			delivery.Phone = field.string()
+		case 3:
+			delivery.Zip = field.string()
+		case 4:
+			delivery.City = field.string()
+		case 5:
+			delivery.Address = field.string()
+		case 6:
+			delivery.Region = field.string()
+		case 7:
+			delivery.Email = field.string()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func decodeProtoPayment(data []byte) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := walkProto(data, func(field protoField) error {
+		switch field.number {
+		case 1:
+			payment.Transaction = field.string()
+		case 2:
+			payment.RequestID = field.string()
+		case 3:
+			payment.Currency = field.string()
+		case 4:
+			payment.Provider = field.string()
+		case 5:
+			payment.Amount = field.double()
+		case 6:
+			payment.PaymentDt = field.int()
+		case 7:
+			payment.Bank = field.string()
+		case 8:
+			payment.DeliveryCost = field.double()
+		case 9:
+			payment.GoodsTotal = field.double()
+		case 10:
+			payment.CustomFee = field.double()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func decodeProtoItem(data []byte) (domain.Item, error) {
+	var item domain.Item
+	err := walkProto(data, func(field protoField) error {
+		switch field.number {
+		case 1:
+			item.ChrtID = field.int()
+		case 2:
+			item.TrackNumber = field.string()
+		case 3:
+			item.Price = field.double()
+		case 4:
+			item.RID = field.string()
+		case 5:
+			item.Name = field.string()
+		case 6:
+			item.Sale = int(field.int())
+		case 7:
+			item.Size = field.string()
+		case 8:
+			item.TotalPrice = field.double()
+		case 9:
+			item.NmID = field.int()
+		case 10:
+			item.Brand = field.string()
+		case 11:
+			item.Status = int(field.int())
+		}
+		return nil
+	})
+	return item, err
+}
+
+func decodeProtoTimestamp(data []byte) (time.Time, error) {
+	var seconds, nanos int64
+	err := walkProto(data, func(field protoField) error {
+		switch field.number {
+		case 1:
+			seconds = field.int()
+		case 2:
+			nanos = field.int()
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
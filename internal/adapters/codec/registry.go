@@ -0,0 +1,124 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+)
+
+const (
+	fileRegistryScheme  = "file://"
+	registryContentType = "application/vnd.schemaregistry.v1+json"
+	defaultRegistryWait = 5 * time.Second
+)
+
+var (
+	ErrRegistryNotConfigured = errors.New("schema registry is not configured")
+	ErrRegistryUnavailable   = errors.New("schema registry unavailable")
+	ErrSchemaNotFound        = errors.New("schema not found")
+)
+
+var schemaFileExtensions = []string{".avsc", ".proto", ".json"}
+
+func NewSchemaRegistry(cfg config.SchemaRegistryConfig) ports.SchemaRegistry {
+	switch {
+	case cfg.URL == "":
+		return nil
+	case strings.HasPrefix(cfg.URL, fileRegistryScheme):
+		return &fileRegistry{dir: strings.TrimPrefix(cfg.URL, fileRegistryScheme)}
+	default:
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = defaultRegistryWait
+		}
+		return &httpRegistry{
+			client:   &http.Client{Timeout: timeout},
+			baseURL:  strings.TrimRight(cfg.URL, "/"),
+			username: cfg.Username,
+			password: cfg.Password,
+			schemas:  make(map[int]string),
+		}
+	}
+}
+
+type httpRegistry struct {
+	client   *http.Client
+	schemas  map[int]string
+	baseURL  string
+	username string
+	password string
+	mu       sync.RWMutex
+}
+
+func (r *httpRegistry) Schema(ctx context.Context, id int) (string, error) {
+	r.mu.RLock()
+	schema, cached := r.schemas[id]
+	r.mu.RUnlock()
+	if cached {
+		return schema, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.baseURL+"/schemas/ids/"+strconv.Itoa(id), nil)
+	if err != nil {
+		return "", fmt.Errorf("build schema request: %w", err)
+	}
+	req.Header.Set("Accept", registryContentType)
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrRegistryUnavailable, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: id %d", ErrSchemaNotFound, id)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("%w: status %d", ErrRegistryUnavailable, resp.StatusCode)
+	}
+
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("%w: decode response: %w", ErrRegistryUnavailable, err)
+	}
+
+	r.mu.Lock()
+	r.schemas[id] = body.Schema
+	r.mu.Unlock()
+	return body.Schema, nil
+}
+
+// fileRegistry serves schemas from <dir>/<id>.avsc, .proto or .json.
+type fileRegistry struct {
+	dir string
+}
+
+func (r *fileRegistry) Schema(_ context.Context, id int) (string, error) {
+	for _, ext := range schemaFileExtensions {
+		data, err := os.ReadFile(filepath.Join(r.dir, strconv.Itoa(id)+ext))
+		if err == nil {
+			return string(data), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("%w: %w", ErrRegistryUnavailable, err)
+		}
+	}
+	return "", fmt.Errorf("%w: id %d in %s", ErrSchemaNotFound, id, r.dir)
+}
@@ -0,0 +1,330 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	createStagingTablesSQL = `
+        CREATE TEMP TABLE orders_staging ON COMMIT DROP AS
+            SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
+                   delivery_service, shardkey, sm_id, date_created, oof_shard, raw
+            FROM orders WITH NO DATA;
+        CREATE TEMP TABLE delivery_staging ON COMMIT DROP AS
+            SELECT order_uid, name, phone, zip, city, address, region, email
+            FROM delivery WITH NO DATA;
+        CREATE TEMP TABLE payment_staging ON COMMIT DROP AS
+            SELECT order_uid, transaction, request_id, currency, provider, amount,
+                   payment_dt, bank, delivery_cost, goods_total, custom_fee
+            FROM payment WITH NO DATA;
+        CREATE TEMP TABLE items_staging ON COMMIT DROP AS
+            SELECT order_uid, chrt_id, track_number, price, rid, name, sale,
+                   size, total_price, nm_id, brand, status
+            FROM items WITH NO DATA;`
+
+	upsertOrdersFromStagingSQL = `
+        INSERT INTO orders (
+            order_uid, track_number, entry, locale, internal_signature,
+            customer_id, delivery_service, shardkey, sm_id, date_created,
+            oof_shard, raw
+        )
+        SELECT order_uid, track_number, entry, locale, internal_signature,
+               customer_id, delivery_service, shardkey, sm_id, date_created,
+               oof_shard, raw
+        FROM orders_staging
+        ON CONFLICT (order_uid)
+        DO UPDATE SET
+            track_number = EXCLUDED.track_number,
+            entry = EXCLUDED.entry,
+            locale = EXCLUDED.locale,
+            internal_signature = EXCLUDED.internal_signature,
+            customer_id = EXCLUDED.customer_id,
+            delivery_service = EXCLUDED.delivery_service,
+            shardkey = EXCLUDED.shardkey,
+            sm_id = EXCLUDED.sm_id,
+            date_created = EXCLUDED.date_created,
+            oof_shard = EXCLUDED.oof_shard,
+            raw = EXCLUDED.raw,
+            version = orders.version + 1,
+            updated_at = now()
+        RETURNING order_uid, version, created_at, updated_at`
+
+	upsertDeliveryFromStagingSQL = `
+        INSERT INTO delivery (order_uid, name, phone, zip, city, address, region, email)
+        SELECT order_uid, name, phone, zip, city, address, region, email
+        FROM delivery_staging
+        ON CONFLICT (order_uid)
+        DO UPDATE SET
+            name = EXCLUDED.name,
+            phone = EXCLUDED.phone,
+            zip = EXCLUDED.zip,
+            city = EXCLUDED.city,
+            address = EXCLUDED.address,
+            region = EXCLUDED.region,
+            email = EXCLUDED.email`
+
+	upsertPaymentFromStagingSQL = `
+        INSERT INTO payment (
+            order_uid, transaction, request_id, currency, provider, amount,
+            payment_dt, bank, delivery_cost, goods_total, custom_fee
+        )
+        SELECT order_uid, transaction, request_id, currency, provider, amount,
+               payment_dt, bank, delivery_cost, goods_total, custom_fee
+        FROM payment_staging
+        ON CONFLICT (order_uid)
+        DO UPDATE SET
+            transaction = EXCLUDED.transaction,
+            request_id = EXCLUDED.request_id,
+            currency = EXCLUDED.currency,
+            provider = EXCLUDED.provider,
+            amount = EXCLUDED.amount,
+            payment_dt = EXCLUDED.payment_dt,
+            bank = EXCLUDED.bank,
+            delivery_cost = EXCLUDED.delivery_cost,
+            goods_total = EXCLUDED.goods_total,
+            custom_fee = EXCLUDED.custom_fee`
+
+	deleteStagedItemsSQL = `DELETE FROM items WHERE order_uid IN (SELECT order_uid FROM orders_staging)`
+
+	insertItemsFromStagingSQL = `
+        INSERT INTO items (
+            order_uid, chrt_id, track_number, price, rid, name, sale,
+            size, total_price, nm_id, brand, status
+        )
+        SELECT order_uid, chrt_id, track_number, price, rid, name, sale,
+               size, total_price, nm_id, brand, status
+        FROM items_staging`
+)
+
+var ErrEmptyBatch = errors.New("batch contains no orders")
+
+func (r *orderRepository) SaveOrdersTx(ctx context.Context, orders []*domain.Order) (err error) {
+	ctx, span := tracer.Start(ctx, "orderRepository.SaveOrdersTx", trace.WithAttributes(attribute.Int("batch.size", len(orders))))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	batch, err := dedupeOrders(orders)
+	if err != nil {
+		r.log.WithContext(ctx).Warn("invalid order data in batch, skipping", "batch_size", len(orders), "error", err)
+		return err
+	}
+
+	transaction, err := r.db.Pool().Begin(ctx)
+	if err != nil {
+		r.log.WithContext(ctx).Error("failed to begin batch transaction", "batch_size", len(batch), "error", err)
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if rollbackErr := transaction.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			r.log.WithContext(ctx).Error("failed to rollback batch transaction", "batch_size", len(batch), "error", rollbackErr)
+		}
+	}()
+
+	if err := r.stageOrders(ctx, transaction, batch); err != nil {
+		return err
+	}
+
+	if err := r.upsertStagedOrders(ctx, transaction, batch); err != nil {
+		return err
+	}
+
+	if err = transaction.Commit(ctx); err != nil {
+		r.log.WithContext(ctx).Error("failed to commit batch transaction", "batch_size", len(batch), "error", err)
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	r.log.WithContext(ctx).Info("order batch saved successfully", "batch_size", len(batch))
+	return nil
+}
+
+func dedupeOrders(orders []*domain.Order) ([]*domain.Order, error) {
+	if len(orders) == 0 {
+		return nil, ErrEmptyBatch
+	}
+
+	positions := make(map[string]int, len(orders))
+	batch := make([]*domain.Order, 0, len(orders))
+	for _, order := range orders {
+		if order == nil {
+			return nil, fmt.Errorf("%w: nil order in batch", ErrInvalidOrder)
+		}
+		if err := validateOrder(order); err != nil {
+			return nil, fmt.Errorf("order %q: %w", order.OrderUID, err)
+		}
+
+		if position, exists := positions[order.OrderUID]; exists {
+			batch[position] = order
+			continue
+		}
+		positions[order.OrderUID] = len(batch)
+		batch = append(batch, order)
+	}
+
+	return batch, nil
+}
+
+func (r *orderRepository) stageOrders(ctx context.Context, transaction pgx.Tx, orders []*domain.Order) error {
+	if _, err := transaction.Exec(ctx, createStagingTablesSQL); err != nil {
+		r.log.WithContext(ctx).Error("failed to create staging tables", "error", err)
+		return fmt.Errorf("create staging tables: %w", err)
+	}
+
+	orderRows := make([][]any, 0, len(orders))
+	deliveryRows := make([][]any, 0, len(orders))
+	paymentRows := make([][]any, 0, len(orders))
+	var itemRows [][]any
+
+	for _, order := range orders {
+		rawData, err := json.Marshal(order)
+		if err != nil {
+			r.log.WithContext(ctx).Error("failed to marshal order to JSON", "order_uid", order.OrderUID, "error", err)
+			return fmt.Errorf("marshal order to JSON: %w", err)
+		}
+		order.Raw = rawData
+
+		orderRows = append(orderRows, []any{
+			order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
+			order.CustomerID, order.DeliveryService, order.Shardkey, order.SmID, order.DateCreated,
+			order.OofShard, rawData,
+		})
+
+		if order.Delivery != nil {
+			deliveryRows = append(deliveryRows, []any{
+				order.OrderUID, order.Delivery.Name, order.Delivery.Phone, order.Delivery.Zip,
+				order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email,
+			})
+		}
+
+		if order.Payment != nil {
+			paymentRows = append(paymentRows, []any{
+				order.OrderUID, order.Payment.Transaction, order.Payment.RequestID, order.Payment.Currency,
+				order.Payment.Provider, order.Payment.Amount, order.Payment.PaymentDt, order.Payment.Bank,
+				order.Payment.DeliveryCost, order.Payment.GoodsTotal, order.Payment.CustomFee,
+			})
+		}
+
+		for _, item := range order.Items {
+			itemRows = append(itemRows, []any{
+				order.OrderUID, item.ChrtID, item.TrackNumber, item.Price, item.RID,
+				item.Name, item.Sale, item.Size, item.TotalPrice, item.NmID,
+				item.Brand, item.Status,
+			})
+		}
+	}
+
+	stagingCopies := []struct {
+		table   string
+		columns []string
+		rows    [][]any
+	}{
+		{
+			table: "orders_staging",
+			columns: []string{
+				"order_uid", "track_number", "entry", "locale", "internal_signature", "customer_id",
+				"delivery_service", "shardkey", "sm_id", "date_created", "oof_shard", "raw",
+			},
+			rows: orderRows,
+		},
+		{
+			table:   "delivery_staging",
+			columns: []string{"order_uid", "name", "phone", "zip", "city", "address", "region", "email"},
+			rows:    deliveryRows,
+		},
+		{
+			table: "payment_staging",
+			columns: []string{
+				"order_uid", "transaction", "request_id", "currency", "provider", "amount",
+				"payment_dt", "bank", "delivery_cost", "goods_total", "custom_fee",
+			},
+			rows: paymentRows,
+		},
+		{
+			table: "items_staging",
+			columns: []string{
+				"order_uid", "chrt_id", "track_number", "price", "rid", "name", "sale",
+				"size", "total_price", "nm_id", "brand", "status",
+			},
+			rows: itemRows,
+		},
+	}
+
+	for _, staging := range stagingCopies {
+		if len(staging.rows) == 0 {
+			continue
+		}
+		if _, err := transaction.CopyFrom(ctx, pgx.Identifier{staging.table}, staging.columns, pgx.CopyFromRows(staging.rows)); err != nil {
+			r.log.WithContext(ctx).Error("failed to copy rows into staging table", "table", staging.table, "rows", len(staging.rows), "error", err)
+			return fmt.Errorf("copy into %s: %w", staging.table, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *orderRepository) upsertStagedOrders(ctx context.Context, transaction pgx.Tx, orders []*domain.Order) error {
+	rows, err := transaction.Query(ctx, upsertOrdersFromStagingSQL)
+	if err != nil {
+		r.log.WithContext(ctx).Error("failed to upsert staged orders", "error", err)
+		return fmt.Errorf("upsert staged orders: %w", err)
+	}
+
+	byUID := make(map[string]*domain.Order, len(orders))
+	for _, order := range orders {
+		byUID[order.OrderUID] = order
+	}
+
+	for rows.Next() {
+		var orderUID string
+		var version int64
+		var createdAt, updatedAt time.Time
+		if err := rows.Scan(&orderUID, &version, &createdAt, &updatedAt); err != nil {
+			rows.Close()
+			r.log.WithContext(ctx).Error("failed to scan upserted order", "error", err)
+			return fmt.Errorf("scan upserted order: %w", err)
+		}
+		if order, exists := byUID[orderUID]; exists {
+			order.Version = version
+			order.CreatedAt = createdAt
+			order.UpdatedAt = updatedAt
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		r.log.WithContext(ctx).Error("failed to upsert staged orders", "error", err)
+		return fmt.Errorf("upsert staged orders: %w", err)
+	}
+
+	statements := []struct {
+		name string
+		sql  string
+	}{
+		{name: "upsert staged delivery", sql: upsertDeliveryFromStagingSQL},
+		{name: "upsert staged payment", sql: upsertPaymentFromStagingSQL},
+		{name: "delete old items", sql: deleteStagedItemsSQL},
+		{name: "insert staged items", sql: insertItemsFromStagingSQL},
+	}
+
+	for _, statement := range statements {
+		if _, err := transaction.Exec(ctx, statement.sql); err != nil {
+			r.log.WithContext(ctx).Error("failed to apply staged batch", "step", statement.name, "error", err)
+			return fmt.Errorf("%s: %w", statement.name, err)
+		}
+	}
+
+	if err := r.enqueueOrderEvents(ctx, transaction, orders...); err != nil {
+		return err
+	}
+	return r.notifyOrderChanges(ctx, transaction, orders...)
+}
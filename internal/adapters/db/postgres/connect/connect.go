@@ -10,7 +10,9 @@ import (
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/db/postgres/migration"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -19,6 +21,8 @@ var (
 	ErrMinConnsExceeded = errors.New("minConns exceeds maximum allowed value")
 	ErrInvalidConnRange = errors.New("minConns cannot be greater than maxConns")
 	ErrContextTimeout   = errors.New("database connection timeout exceeded")
+	ErrDirtyMigration   = errors.New("database schema is dirty after a failed migration")
+	ErrSchemaOutdated   = errors.New("database schema is older than the service expects")
 )
 
 type DB struct {
@@ -49,6 +53,8 @@ func NewPool(ctx context.Context, dsn string, maxConns, minConns int, connMaxLif
 		return nil, fmt.Errorf("pgxpool.ParseConfig: %w", err)
 	}
 
+	cfg.ConnConfig.Tracer = newQueryTracer()
+
 	if maxConns > 0 {
 		if maxConns > math.MaxInt32 {
 			return nil, fmt.Errorf("%w: %d", ErrMaxConnsExceeded, maxConns)
@@ -157,9 +163,85 @@ func (d *DB) Pool() *pgxpool.Pool {
 	return d.pool
 }
 
+// Connect opens a dedicated, untraced connection for sessions such as LISTEN.
+func (d *DB) Connect(ctx context.Context) (*pgx.Conn, error) {
+	connConfig := d.pool.Config().ConnConfig.Copy()
+	connConfig.Tracer = nil
+
+	conn, err := pgx.ConnectConfig(ctx, connConfig)
+	if err != nil {
+		return nil, fmt.Errorf("connect: %w", err)
+	}
+	return conn, nil
+}
+
 func (d *DB) Ping(ctx context.Context) error {
 	if err := d.pool.Ping(ctx); err != nil {
 		return fmt.Errorf("ping database: %w", err)
 	}
 	return nil
 }
+
+func (d *DB) Stats() domain.PoolStats {
+	stat := d.pool.Stat()
+	return domain.PoolStats{
+		AcquireDuration:      stat.AcquireDuration(),
+		AcquireCount:         stat.AcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		TotalConns:           stat.TotalConns(),
+		MaxConns:             stat.MaxConns(),
+	}
+}
+
+const selectMigrationVersionSQL = `SELECT version, dirty FROM schema_migrations LIMIT 1`
+
+func (d *DB) MigrationVersion(ctx context.Context) (int64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	if err := d.pool.QueryRow(ctx, selectMigrationVersionSQL).Scan(&version, &dirty); err != nil {
+		return 0, false, fmt.Errorf("select migration version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+func (d *DB) HealthCheck(ctx context.Context) (map[string]any, error) {
+	if err := d.Ping(ctx); err != nil {
+		return nil, err
+	}
+
+	stats := d.Stats()
+	return map[string]any{
+		"total_connections":    stats.TotalConns,
+		"acquired_connections": stats.AcquiredConns,
+	}, nil
+}
+
+// MigrationCheck fails while the schema is dirty or behind the shipped migrations.
+func (d *DB) MigrationCheck(migrationsPath string) func(context.Context) (map[string]any, error) {
+	expected, expectedErr := migration.LatestVersion(migrationsPath)
+
+	return func(ctx context.Context) (map[string]any, error) {
+		version, dirty, err := d.MigrationVersion(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		details := map[string]any{"version": version, "dirty": dirty}
+		if expectedErr == nil {
+			details["expected_version"] = expected
+		}
+
+		switch {
+		case dirty:
+			return details, fmt.Errorf("%w: version %d", ErrDirtyMigration, version)
+		case expectedErr == nil && version < int64(expected):
+			return details, fmt.Errorf("%w: version %d, expected %d", ErrSchemaOutdated, version, expected)
+		}
+		return details, nil
+	}
+}
@@ -0,0 +1,76 @@
+package connect
+
+import (
+	"context"
+	"strings"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const maxSpanNameLen = 64
+
+type queryTracer struct {
+	tracer trace.Tracer
+}
+
+func newQueryTracer() *queryTracer {
+	return &queryTracer{tracer: tracing.Tracer("adapters/db/postgres")}
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, querySpanName(data.SQL),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.query.text", strings.TrimSpace(data.SQL)),
+		))
+	return ctx
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	tracing.RecordError(span, data.Err)
+	span.SetAttributes(attribute.Int64("db.response.returned_rows", data.CommandTag.RowsAffected()))
+	span.End()
+}
+
+func (t *queryTracer) TraceCopyFromStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromStartData) context.Context {
+	ctx, _ = t.tracer.Start(ctx, "COPY "+data.TableName.Sanitize(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.collection.name", data.TableName.Sanitize()),
+		))
+	return ctx
+}
+
+func (t *queryTracer) TraceCopyFromEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceCopyFromEndData) {
+	span := trace.SpanFromContext(ctx)
+	tracing.RecordError(span, data.Err)
+	span.SetAttributes(attribute.Int64("db.response.returned_rows", data.CommandTag.RowsAffected()))
+	span.End()
+}
+
+func querySpanName(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "postgres"
+	}
+
+	name := strings.ToUpper(fields[0])
+	for i, field := range fields {
+		upper := strings.ToUpper(field)
+		if (upper == "FROM" || upper == "INTO" || upper == "UPDATE") && i+1 < len(fields) {
+			name += " " + strings.Trim(fields[i+1], "(,;")
+			break
+		}
+	}
+
+	if len(name) > maxSpanNameLen {
+		return name[:maxSpanNameLen]
+	}
+	return name
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var retryableSQLStates = map[string]struct{}{
+	"40001": {}, // serialization_failure
+	"40P01": {}, // deadlock_detected
+	"55P03": {}, // lock_not_available
+	"57P01": {}, // admin_shutdown
+	"57P02": {}, // crash_shutdown
+	"57P03": {}, // cannot_connect_now
+	"58000": {}, // system_error
+	"58030": {}, // io_error
+}
+
+var retryableSQLStateClasses = map[string]struct{}{
+	"08": {}, // connection_exception
+	"53": {}, // insufficient_resources
+}
+
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, ErrEmptyOrderUID) || errors.Is(err, ErrInvalidOrder) || errors.Is(err, ErrEmptyBatch) {
+		return false
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return isRetryableSQLState(pgErr.Code)
+	}
+
+	return true
+}
+
+func isRetryableSQLState(code string) bool {
+	if _, ok := retryableSQLStates[code]; ok {
+		return true
+	}
+	if len(code) < 2 {
+		return false
+	}
+	_, ok := retryableSQLStateClasses[code[:2]]
+	return ok
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/db/postgres/connect"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// claimIdempotencyKeySQL takes over expired and abandoned claims, else returns no row.
+	// A takeover replaces claim_token, which fences off the previous owner.
+	claimIdempotencyKeySQL = `
+        INSERT INTO idempotency_keys (key, request_hash, claim_token)
+        VALUES ($1, $2, $5)
+        ON CONFLICT (key) DO UPDATE SET
+            request_hash = EXCLUDED.request_hash,
+            claim_token = EXCLUDED.claim_token,
+            status_code = NULL,
+            response = NULL,
+            created_at = now(),
+            completed_at = NULL
+        WHERE idempotency_keys.created_at < $3
+           OR (idempotency_keys.completed_at IS NULL AND idempotency_keys.created_at < $4)
+        RETURNING key`
+
+	selectIdempotencyKeySQL = `
+        SELECT request_hash, status_code, response, created_at
+        FROM idempotency_keys
+        WHERE key = $1`
+
+	completeIdempotencyKeySQL = `
+        UPDATE idempotency_keys
+        SET status_code = $3, response = $4, completed_at = now()
+        WHERE key = $1 AND claim_token = $2 AND completed_at IS NULL`
+
+	releaseIdempotencyKeySQL = `DELETE FROM idempotency_keys WHERE key = $1 AND claim_token = $2 AND completed_at IS NULL`
+
+	deleteExpiredIdempotencyKeysSQL = `DELETE FROM idempotency_keys WHERE created_at < $1`
+)
+
+// claimAttempts retries claims of keys deleted between the insert and the select.
+const claimAttempts = 3
+
+type idempotencyStore struct {
+	db  *connect.DB
+	log logger.Logger
+}
+
+func NewIdempotencyStore(db *connect.DB, log logger.Logger) ports.IdempotencyStore {
+	return &idempotencyStore{
+		db:  db,
+		log: log,
+	}
+}
+
+func (s *idempotencyStore) Claim(ctx context.Context, key, requestHash string, expiredBefore, abandonedBefore time.Time) (_ string, _ *domain.IdempotencyRecord, err error) {
+	ctx, span := tracer.Start(ctx, "idempotencyStore.Claim")
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	for range claimAttempts {
+		var claimed string
+		token := rand.Text()
+		err := s.db.Pool().QueryRow(ctx, claimIdempotencyKeySQL, key, requestHash, expiredBefore, abandonedBefore, token).Scan(&claimed)
+		if err == nil {
+			return token, nil, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			s.log.WithContext(ctx).Error("failed to claim idempotency key", "error", err)
+			return "", nil, fmt.Errorf("claim idempotency key: %w", err)
+		}
+
+		record, err := s.load(ctx, key)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			s.log.WithContext(ctx).Error("failed to load idempotency key", "error", err)
+			return "", nil, fmt.Errorf("load idempotency key: %w", err)
+		}
+		return "", record, nil
+	}
+	return "", nil, fmt.Errorf("claim idempotency key: %w", domain.ErrRequestInProgress)
+}
+
+func (s *idempotencyStore) load(ctx context.Context, key string) (*domain.IdempotencyRecord, error) {
+	var (
+		record     domain.IdempotencyRecord
+		statusCode *int
+		response   []byte
+	)
+	if err := s.db.Pool().QueryRow(ctx, selectIdempotencyKeySQL, key).Scan(&record.RequestHash, &statusCode, &response, &record.CreatedAt); err != nil {
+		return nil, err
+	}
+	if statusCode != nil {
+		record.Response = &domain.IdempotentResponse{Body: response, StatusCode: *statusCode}
+	}
+	return &record, nil
+}
+
+func (s *idempotencyStore) Complete(ctx context.Context, key, token string, response domain.IdempotentResponse) error {
+	tag, err := s.db.Pool().Exec(ctx, completeIdempotencyKeySQL, key, token, response.StatusCode, response.Body)
+	if err != nil {
+		s.log.WithContext(ctx).Error("failed to store idempotent response", "error", err)
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("complete idempotency key: %w", domain.ErrIdempotencyClaimLost)
+	}
+	return nil
+}
+
+// Release leaves a claim that was taken over to its new owner.
+func (s *idempotencyStore) Release(ctx context.Context, key, token string) error {
+	if _, err := s.db.Pool().Exec(ctx, releaseIdempotencyKeySQL, key, token); err != nil {
+		s.log.WithContext(ctx).Error("failed to release idempotency key", "error", err)
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (s *idempotencyStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.db.Pool().Exec(ctx, deleteExpiredIdempotencyKeysSQL, before)
+	if err != nil {
+		s.log.WithContext(ctx).Error("failed to delete expired idempotency keys", "error", err)
+		return 0, fmt.Errorf("delete expired idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
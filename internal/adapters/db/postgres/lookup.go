@@ -0,0 +1,64 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const lookupOrderSQL = selectOrderColumnsSQL + `
+        WHERE %s
+        ORDER BY o.created_at DESC, o.order_uid DESC
+        LIMIT $2`
+
+// lookupConditions match an order by a secondary key passed as $1.
+var lookupConditions = map[domain.OrderIndex]string{
+	domain.IndexTrackNumber: `o.track_number = $1`,
+	domain.IndexCustomerID:  `o.customer_id = $1`,
+	domain.IndexItemRID:     `EXISTS (SELECT 1 FROM items it WHERE it.order_uid = o.order_uid AND it.rid = $1)`,
+	domain.IndexItemNmID:    `EXISTS (SELECT 1 FROM items it WHERE it.order_uid = o.order_uid AND it.nm_id = $1)`,
+}
+
+// FindOrders returns up to limit orders carrying key in the index, newest first.
+func (r *orderRepository) FindOrders(ctx context.Context, index domain.OrderIndex, key string, limit int) (_ []*domain.Order, err error) {
+	ctx, span := tracer.Start(ctx, "orderRepository.FindOrders", trace.WithAttributes(
+		attribute.String("lookup.index", string(index)),
+		attribute.Int("limit", limit),
+	))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	if err := index.ValidateKey(key); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+
+	var arg any = key
+	if index == domain.IndexItemNmID {
+		arg, _ = strconv.ParseInt(key, 10, 64)
+	}
+
+	rows, err := r.db.Pool().Query(ctx, fmt.Sprintf(lookupOrderSQL, lookupConditions[index]), arg, limit)
+	if err != nil {
+		r.log.WithContext(ctx).Error("failed to look up orders", "index", index, "key", key, "error", err)
+		return nil, fmt.Errorf("look up orders by %s: %w", index, err)
+	}
+
+	orders, err := collectOrders(rows, min(limit, defaultPageSize))
+	if err != nil {
+		r.log.WithContext(ctx).Error("failed to read looked up orders", "index", index, "key", key, "error", err)
+		return nil, fmt.Errorf("read orders by %s: %w", index, err)
+	}
+
+	r.log.WithContext(ctx).Debug("orders looked up", "index", index, "key", key, "orders", len(orders))
+	return orders, nil
+}
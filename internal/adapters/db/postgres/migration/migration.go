@@ -3,6 +3,9 @@ package migration
 import (
 	"errors"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
@@ -13,6 +16,7 @@ var (
 	ErrMigrationInit   = errors.New("failed to initialize migration")
 	ErrMigrationFailed = errors.New("migration execution failed")
 	ErrMigrationClose  = errors.New("failed to close migrator")
+	ErrNoMigrations    = errors.New("no migrations found")
 )
 
 type MigrationRunner struct {
@@ -74,3 +78,34 @@ func (mr *MigrationRunner) Close() error {
 	}
 	return nil
 }
+
+// LatestVersion returns the highest version among the up migrations in migrationsPath.
+func LatestVersion(migrationsPath string) (uint, error) {
+	entries, err := os.ReadDir(migrationsPath)
+	if err != nil {
+		return 0, fmt.Errorf("read migrations dir: %w", err)
+	}
+
+	var latest uint
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+
+		versionPart, _, found := strings.Cut(name, "_")
+		if !found {
+			continue
+		}
+		version, err := strconv.ParseUint(versionPart, 10, 64)
+		if err != nil {
+			continue
+		}
+		latest = max(latest, uint(version))
+	}
+
+	if latest == 0 {
+		return 0, fmt.Errorf("%w in %s", ErrNoMigrations, migrationsPath)
+	}
+	return latest, nil
+}
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/db/postgres/connect"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// OrderChangesChannel carries one JSON domain.OrderChange per written order.
+const OrderChangesChannel = "order_changes"
+
+const (
+	// Notifications are delivered on commit and dropped on rollback.
+	notifyOrderChangesSQL = `
+        SELECT pg_notify($1, payload)
+        FROM unnest($2::text[]) AS payload`
+
+	// maxNotifyPayload keeps payloads under the 8000-byte pg_notify limit.
+	maxNotifyPayload = 7900
+
+	closeListenerTimeout = 5 * time.Second
+)
+
+func (r *orderRepository) notifyOrderChanges(ctx context.Context, transaction Queryable, orders ...*domain.Order) error {
+	payloads := make([]string, len(orders))
+	for i, order := range orders {
+		payload, err := encodeOrderChange(domain.NewOrderChange(order))
+		if err != nil {
+			return err
+		}
+		payloads[i] = payload
+	}
+
+	if _, err := transaction.Exec(ctx, notifyOrderChangesSQL, OrderChangesChannel, payloads); err != nil {
+		r.log.WithContext(ctx).Error("failed to notify order changes", "orders", len(orders), "error", err)
+		return fmt.Errorf("notify order changes: %w", err)
+	}
+	return nil
+}
+
+// encodeOrderChange drops the index keys when they would not fit.
+func encodeOrderChange(change domain.OrderChange) (string, error) {
+	payload, err := json.Marshal(change)
+	if err == nil && len(payload) > maxNotifyPayload {
+		change.Keys = nil
+		payload, err = json.Marshal(change)
+	}
+	if err != nil {
+		return "", fmt.Errorf("encode order change: %w", err)
+	}
+	return string(payload), nil
+}
+
+type orderChangeFeed struct {
+	db           *connect.DB
+	log          logger.Logger
+	pingInterval time.Duration
+}
+
+func NewOrderChangeFeed(db *connect.DB, pingInterval time.Duration, log logger.Logger) ports.OrderChangeFeed {
+	return &orderChangeFeed{
+		db:           db,
+		log:          log,
+		pingInterval: pingInterval,
+	}
+}
+
+// Listen holds one LISTEN session until ctx is done or the connection breaks.
+func (f *orderChangeFeed) Listen(ctx context.Context, subscribed func(context.Context), onChange func(context.Context, domain.OrderChange)) error {
+	conn, err := f.db.Connect(ctx)
+	if err != nil {
+		return fmt.Errorf("open listener connection: %w", err)
+	}
+	defer func() {
+		closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), closeListenerTimeout)
+		defer cancel()
+		if err := conn.Close(closeCtx); err != nil {
+			f.log.Warn("failed to close listener connection", "error", err)
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{OrderChangesChannel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen %s: %w", OrderChangesChannel, err)
+	}
+	subscribed(ctx)
+
+	for {
+		notification, err := f.wait(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if notification == nil {
+			continue
+		}
+
+		var change domain.OrderChange
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil || change.OrderUID == "" {
+			f.log.Warn("invalid order change notification", "payload", notification.Payload, "error", err)
+			continue
+		}
+		onChange(ctx, change)
+	}
+}
+
+// wait returns the next notification, or nil after a successful keep-alive ping.
+func (f *orderChangeFeed) wait(ctx context.Context, conn *pgx.Conn) (*pgconn.Notification, error) {
+	waitCtx := ctx
+	if f.pingInterval > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, f.pingInterval)
+		defer cancel()
+	}
+
+	notification, err := conn.WaitForNotification(waitCtx)
+	switch {
+	case err == nil:
+		return notification, nil
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("wait for notification: %w", ctx.Err())
+	case pgconn.Timeout(err) || errors.Is(err, context.DeadlineExceeded):
+		if err := conn.Ping(ctx); err != nil {
+			return nil, fmt.Errorf("ping listener connection: %w", err)
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("wait for notification: %w", err)
+	}
+}
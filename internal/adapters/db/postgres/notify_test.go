@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+)
+
+func TestEncodeOrderChange(t *testing.T) {
+	order := &domain.Order{OrderUID: "b563feb7b2b84b6test", CustomerID: "test", Version: 3}
+	order.Items = append(order.Items, domain.Item{RID: "ab4219087a764ae0btest", NmID: 2389212})
+
+	payload, err := encodeOrderChange(domain.NewOrderChange(order))
+	if err != nil {
+		t.Fatalf("encodeOrderChange() error = %v", err)
+	}
+
+	var change domain.OrderChange
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		t.Fatalf("decode payload %s: %v", payload, err)
+	}
+	if change.OrderUID != order.OrderUID || change.Version != 3 {
+		t.Fatalf("decoded change = %+v", change)
+	}
+	if got := change.Keys[domain.IndexCustomerID]; len(got) != 1 || got[0] != "test" {
+		t.Fatalf("customer_id keys = %v, want [test]", got)
+	}
+	if got := change.Keys[domain.IndexItemNmID]; len(got) != 1 || got[0] != "2389212" {
+		t.Fatalf("nm_id keys = %v, want [2389212]", got)
+	}
+	if _, listed := change.Keys[domain.IndexTrackNumber]; listed {
+		t.Fatal("unique track_number index listed in the change keys")
+	}
+}
+
+func TestEncodeOrderChangeDropsKeysOverLimit(t *testing.T) {
+	order := &domain.Order{OrderUID: "b563feb7b2b84b6test", CustomerID: "test", Version: 1}
+	for i := range 1000 {
+		order.Items = append(order.Items, domain.Item{RID: "rid-" + strconv.Itoa(i), NmID: int64(i + 1)})
+	}
+
+	payload, err := encodeOrderChange(domain.NewOrderChange(order))
+	if err != nil {
+		t.Fatalf("encodeOrderChange() error = %v", err)
+	}
+	if len(payload) > maxNotifyPayload {
+		t.Fatalf("payload is %d bytes, over the %d limit", len(payload), maxNotifyPayload)
+	}
+
+	var change domain.OrderChange
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if change.Keys != nil || change.OrderUID != order.OrderUID {
+		t.Fatalf("decoded change = %+v, want the keys left out", change)
+	}
+}
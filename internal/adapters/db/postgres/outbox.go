@@ -0,0 +1,232 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/db/postgres/connect"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/retry"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+	"github.com/jackc/pgx/v5"
+)
+
+const outboxBackoffJitter = 0.2
+
+const (
+	selectPendingOutboxSQL = `
+        SELECT id, aggregate_id, event_type, payload, attempts, created_at
+        FROM outbox
+        WHERE published_at IS NULL AND parked_at IS NULL AND next_attempt_at <= now()
+        ORDER BY id
+        LIMIT $1
+        FOR UPDATE SKIP LOCKED`
+
+	markOutboxPublishedSQL = `
+        UPDATE outbox
+        SET published_at = now(), attempts = attempts + 1, last_error = NULL
+        WHERE id = ANY($1)`
+
+	markOutboxFailedSQL = `
+        UPDATE outbox
+        SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3,
+            parked_at = CASE WHEN $4 THEN now() END
+        WHERE id = $1`
+
+	deletePublishedOutboxSQL = `DELETE FROM outbox WHERE published_at < $1`
+)
+
+func (r *orderRepository) enqueueOrderEvents(ctx context.Context, transaction Queryable, orders ...*domain.Order) error {
+	if !r.outbox {
+		return nil
+	}
+
+	rows := make([][]any, 0, len(orders))
+	for _, order := range orders {
+		payload, err := json.Marshal(domain.NewOrderPersistedEvent(order))
+		if err != nil {
+			r.log.WithContext(ctx).Error("failed to marshal order event", "order_uid", order.OrderUID, "error", err)
+			return fmt.Errorf("marshal order event: %w", err)
+		}
+		rows = append(rows, []any{order.OrderUID, domain.EventOrderPersisted, payload})
+	}
+
+	columns := []string{"aggregate_id", "event_type", "payload"}
+	if _, err := transaction.CopyFrom(ctx, pgx.Identifier{"outbox"}, columns, pgx.CopyFromRows(rows)); err != nil {
+		r.log.WithContext(ctx).Error("failed to write outbox events", "events", len(rows), "error", err)
+		return fmt.Errorf("write outbox events: %w", err)
+	}
+
+	return nil
+}
+
+type outboxStore struct {
+	db          *connect.DB
+	backoff     *retry.Policy
+	log         logger.Logger
+	maxAttempts int
+}
+
+func NewOutboxStore(db *connect.DB, cfg config.OutboxConfig, log logger.Logger) ports.OutboxStore {
+	backoffCfg := config.RetryConfig{BaseBackoff: cfg.BaseBackoff, MaxBackoff: cfg.MaxBackoff, Jitter: outboxBackoffJitter}
+	return &outboxStore{
+		db:          db,
+		backoff:     retry.NewPolicy(backoffCfg, nil),
+		log:         log,
+		maxAttempts: max(cfg.MaxAttempts, 1),
+	}
+}
+
+func (s *outboxStore) PublishPending(ctx context.Context, limit int, publish func(context.Context, []domain.OutboxEvent) error) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "outboxStore.PublishPending")
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	transaction, err := s.db.Pool().Begin(ctx)
+	if err != nil {
+		s.log.WithContext(ctx).Error("failed to begin outbox transaction", "error", err)
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		if rollbackErr := transaction.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
+			s.log.WithContext(ctx).Error("failed to rollback outbox transaction", "error", rollbackErr)
+		}
+	}()
+
+	events, err := s.lockPending(ctx, transaction, limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	published, failures := publishIsolated(ctx, events, publish)
+
+	if len(published) > 0 {
+		if _, err := transaction.Exec(ctx, markOutboxPublishedSQL, published); err != nil {
+			s.log.WithContext(ctx).Error("failed to mark outbox events published", "events", len(published), "error", err)
+			return 0, fmt.Errorf("mark outbox events published: %w", err)
+		}
+	}
+
+	for _, failure := range failures {
+		if err := s.markFailed(ctx, transaction, failure); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := transaction.Commit(ctx); err != nil {
+		s.log.WithContext(ctx).Error("failed to commit outbox transaction", "error", err)
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	if len(failures) > 0 {
+		return len(published), fmt.Errorf("publish %d outbox events: %w", len(failures), failures[0].err)
+	}
+	return len(published), nil
+}
+
+type outboxFailure struct {
+	err   error
+	event domain.OutboxEvent
+}
+
+// publishIsolated retries a failed batch one event at a time.
+func publishIsolated(ctx context.Context, events []domain.OutboxEvent, publish func(context.Context, []domain.OutboxEvent) error) ([]int64, []outboxFailure) {
+	err := publish(ctx, events)
+	if err == nil {
+		ids := make([]int64, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		return ids, nil
+	}
+	if len(events) == 1 {
+		return nil, []outboxFailure{{event: events[0], err: err}}
+	}
+
+	var (
+		published []int64
+		failures  []outboxFailure
+	)
+	for _, event := range events {
+		if ctx.Err() != nil {
+			failures = append(failures, outboxFailure{event: event, err: ctx.Err()})
+			continue
+		}
+		if err := publish(ctx, []domain.OutboxEvent{event}); err != nil {
+			failures = append(failures, outboxFailure{event: event, err: err})
+			continue
+		}
+		published = append(published, event.ID)
+	}
+	return published, failures
+}
+
+func (s *outboxStore) markFailed(ctx context.Context, transaction pgx.Tx, failure outboxFailure) error {
+	attempts := failure.event.Attempts + 1
+	delay, park := s.schedule(attempts)
+	nextAttempt := time.Now().Add(delay)
+
+	if _, err := transaction.Exec(ctx, markOutboxFailedSQL, failure.event.ID, failure.err.Error(), nextAttempt, park); err != nil {
+		s.log.WithContext(ctx).Error("failed to record outbox publish failure", "event_id", failure.event.ID, "error", err)
+		return fmt.Errorf("mark outbox event failed: %w", err)
+	}
+
+	if park {
+		s.log.WithContext(ctx).Error("outbox event parked after repeated publish failures",
+			"event_id", failure.event.ID,
+			"aggregate_id", failure.event.AggregateID,
+			"attempts", attempts,
+			"error", failure.err)
+	}
+	return nil
+}
+
+func (s *outboxStore) schedule(attempts int) (time.Duration, bool) {
+	return s.backoff.Backoff(attempts), attempts >= s.maxAttempts
+}
+
+func (s *outboxStore) lockPending(ctx context.Context, transaction pgx.Tx, limit int) ([]domain.OutboxEvent, error) {
+	rows, err := transaction.Query(ctx, selectPendingOutboxSQL, limit)
+	if err != nil {
+		s.log.WithContext(ctx).Error("failed to select pending outbox events", "error", err)
+		return nil, fmt.Errorf("select pending outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []domain.OutboxEvent
+	for rows.Next() {
+		var event domain.OutboxEvent
+		if err := rows.Scan(&event.ID, &event.AggregateID, &event.EventType, &event.Payload, &event.Attempts, &event.CreatedAt); err != nil {
+			s.log.WithContext(ctx).Error("failed to scan outbox event", "error", err)
+			return nil, fmt.Errorf("scan outbox event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.log.WithContext(ctx).Error("failed to iterate outbox events", "error", err)
+		return nil, fmt.Errorf("iterate outbox events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *outboxStore) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.db.Pool().Exec(ctx, deletePublishedOutboxSQL, before)
+	if err != nil {
+		s.log.WithContext(ctx).Error("failed to delete published outbox events", "error", err)
+		return 0, fmt.Errorf("delete published outbox events: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
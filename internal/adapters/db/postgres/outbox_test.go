@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+var errPublishFailed = errors.New("broker unavailable")
+
+func nopLogger() logger.Logger {
+	zapLogger := zap.NewNop()
+	return &logger.ZapLogger{Logger: zapLogger, Sugared: zapLogger.Sugar()}
+}
+
+// fakeTransaction records the outbox rows copied into it.
+type fakeTransaction struct {
+	Queryable
+	table pgx.Identifier
+	rows  int
+}
+
+func (f *fakeTransaction) CopyFrom(_ context.Context, table pgx.Identifier, _ []string, rows pgx.CopyFromSource) (int64, error) {
+	f.table = table
+	for rows.Next() {
+		f.rows++
+	}
+	return int64(f.rows), nil
+}
+
+func outboxEvents(ids ...int64) []domain.OutboxEvent {
+	events := make([]domain.OutboxEvent, len(ids))
+	for i, id := range ids {
+		events[i] = domain.OutboxEvent{ID: id}
+	}
+	return events
+}
+
+func failingEvents(failed ...int64) func(context.Context, []domain.OutboxEvent) error {
+	return func(_ context.Context, events []domain.OutboxEvent) error {
+		for _, event := range events {
+			if slices.Contains(failed, event.ID) {
+				return errPublishFailed
+			}
+		}
+		return nil
+	}
+}
+
+func failureIDs(failures []outboxFailure) []int64 {
+	ids := make([]int64, len(failures))
+	for i, failure := range failures {
+		ids[i] = failure.event.ID
+	}
+	return ids
+}
+
+func TestEnqueueOrderEventsUsesSaveTransaction(t *testing.T) {
+	orders := []*domain.Order{{OrderUID: "a"}, {OrderUID: "b"}}
+
+	transaction := &fakeTransaction{}
+	repo := &orderRepository{log: nopLogger(), outbox: true}
+	if err := repo.enqueueOrderEvents(t.Context(), transaction, orders...); err != nil {
+		t.Fatalf("enqueueOrderEvents() error = %v", err)
+	}
+	if transaction.rows != 2 || !slices.Equal(transaction.table, pgx.Identifier{"outbox"}) {
+		t.Fatalf("copied %d rows into %v, want 2 rows into the outbox of the save transaction", transaction.rows, transaction.table)
+	}
+
+	disabled := &fakeTransaction{}
+	repo.outbox = false
+	if err := repo.enqueueOrderEvents(t.Context(), disabled, orders...); err != nil {
+		t.Fatalf("enqueueOrderEvents() error = %v", err)
+	}
+	if disabled.rows != 0 {
+		t.Fatalf("copied %d outbox rows with the outbox disabled", disabled.rows)
+	}
+}
+
+func TestPublishIsolated(t *testing.T) {
+	tests := []struct {
+		name          string
+		failed        []int64
+		wantPublished []int64
+		wantFailed    []int64
+	}{
+		{name: "batch published", wantPublished: []int64{1, 2, 3}},
+		{name: "failed event isolated", failed: []int64{2}, wantPublished: []int64{1, 3}, wantFailed: []int64{2}},
+		{name: "every event failed", failed: []int64{1, 2, 3}, wantFailed: []int64{1, 2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			published, failures := publishIsolated(t.Context(), outboxEvents(1, 2, 3), failingEvents(tt.failed...))
+
+			if !slices.Equal(published, tt.wantPublished) {
+				t.Fatalf("published %v, want %v", published, tt.wantPublished)
+			}
+			if ids := failureIDs(failures); !slices.Equal(ids, tt.wantFailed) {
+				t.Fatalf("failed %v, want %v", ids, tt.wantFailed)
+			}
+		})
+	}
+}
+
+func TestPublishIsolatedAccountsForEveryEventOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	publish := func(_ context.Context, events []domain.OutboxEvent) error {
+		if len(events) > 1 {
+			return errPublishFailed
+		}
+		cancel()
+		return nil
+	}
+
+	published, failures := publishIsolated(ctx, outboxEvents(1, 2, 3), publish)
+
+	if !slices.Equal(published, []int64{1}) || !slices.Equal(failureIDs(failures), []int64{2, 3}) {
+		t.Fatalf("published %v, failed %v; want [1] published and [2 3] kept for retry", published, failureIDs(failures))
+	}
+	for _, failure := range failures {
+		if !errors.Is(failure.err, context.Canceled) {
+			t.Fatalf("failure error = %v, want %v", failure.err, context.Canceled)
+		}
+	}
+}
+
+func TestOutboxScheduleParksAfterMaxAttempts(t *testing.T) {
+	store := NewOutboxStore(nil, config.OutboxConfig{
+		BaseBackoff: time.Second,
+		MaxBackoff:  3 * time.Second,
+		MaxAttempts: 3,
+	}, nopLogger()).(*outboxStore)
+
+	ceilings := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for i, ceiling := range ceilings {
+		attempts := i + 1
+		delay, park := store.schedule(attempts)
+		if delay > ceiling || delay < time.Duration(float64(ceiling)*(1-outboxBackoffJitter)) {
+			t.Fatalf("attempt %d backoff = %s, want up to %s minus jitter", attempts, delay, ceiling)
+		}
+		if wantPark := attempts == 3; park != wantPark {
+			t.Fatalf("attempt %d parked = %t, want %t", attempts, park, wantPark)
+		}
+	}
+}
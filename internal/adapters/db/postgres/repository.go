@@ -5,19 +5,29 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"strings"
+	"time"
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/db/postgres/connect"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/metrics"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("adapters/db/postgres")
+
+const defaultPageSize = 200
+
 var (
 	ErrEmptyOrderUID = errors.New("order_uid cannot be empty")
-	ErrOrderNotFound = errors.New("order not found")
+	ErrOrderNotFound = domain.ErrOrderNotFound
 	ErrInvalidOrder  = errors.New("invalid order data")
 )
 
@@ -41,7 +51,9 @@ const (
             date_created = EXCLUDED.date_created,
             oof_shard = EXCLUDED.oof_shard,
             raw = EXCLUDED.raw,
-            updated_at = now()`
+            version = orders.version + 1,
+            updated_at = now()
+        RETURNING version, created_at, updated_at`
 
 	insertDeliverySQL = `
         INSERT INTO delivery (order_uid, name, phone, zip, city, address, region, email)
@@ -76,35 +88,29 @@ const (
 
 	deleteItemsSQL = `DELETE FROM items WHERE order_uid = $1`
 
-	selectOrderSQL = `
-        SELECT order_uid, track_number, entry, locale, internal_signature, customer_id, 
-               delivery_service, shardkey, sm_id, date_created, oof_shard, raw, created_at, updated_at
-        FROM orders 
-        WHERE order_uid = $1`
-
-	selectDeliverySQL = `
-        SELECT name, phone, zip, city, address, region, email
-        FROM delivery 
-        WHERE order_uid = $1`
-
-	selectPaymentSQL = `
-        SELECT transaction, request_id, currency, provider, amount, payment_dt, 
-               bank, delivery_cost, goods_total, custom_fee, payment_ts
-        FROM payment 
-        WHERE order_uid = $1`
-
-	selectItemsSQL = `
-        SELECT chrt_id, track_number, price, rid, name, sale, size, 
-               total_price, nm_id, brand, status
-        FROM items 
-        WHERE order_uid = $1
-        ORDER BY id`
-
-	selectRecentOrderUIDsSQL = `
-        SELECT order_uid
-        FROM orders 
-        ORDER BY created_at DESC 
-        LIMIT $1`
+	// selectOrderColumnsSQL reads the whole order in one statement, from one snapshot.
+	selectOrderColumnsSQL = `
+        SELECT o.order_uid, o.track_number, o.entry, o.locale, o.internal_signature, o.customer_id,
+               o.delivery_service, o.shardkey, o.sm_id, o.date_created, o.oof_shard, o.raw,
+               o.version, o.created_at, o.updated_at,
+               to_jsonb(d) AS delivery,
+               to_jsonb(p) AS payment,
+               p.payment_ts,
+               COALESCE(
+                   (SELECT json_agg(i ORDER BY i.id) FROM items i WHERE i.order_uid = o.order_uid),
+                   '[]'
+               ) AS items
+        FROM orders o
+        LEFT JOIN delivery d ON d.order_uid = o.order_uid
+        LEFT JOIN payment p ON p.order_uid = o.order_uid`
+
+	selectOrderSQL = selectOrderColumnsSQL + `
+        WHERE o.order_uid = $1`
+
+	selectOrdersByUIDsSQL = selectOrderColumnsSQL + `
+        WHERE o.order_uid = ANY($1)`
+
+	selectChangedOrdersSQL = `SELECT order_uid, version FROM orders WHERE updated_at >= $1`
 
 	insertItemsBaseSQL = `
         INSERT INTO items (
@@ -121,14 +127,16 @@ type Queryable interface {
 }
 
 type orderRepository struct {
-	db  *connect.DB
-	log logger.Logger
+	db     *connect.DB
+	log    logger.Logger
+	outbox bool
 }
 
-func NewOrderRepository(db *connect.DB, log logger.Logger) ports.OrderRepository {
+func NewOrderRepository(db *connect.DB, outbox bool, log logger.Logger) ports.OrderRepository {
 	return &orderRepository{
-		db:  db,
-		log: log,
+		db:     db,
+		log:    log,
+		outbox: outbox,
 	}
 }
 
@@ -147,20 +155,30 @@ func validateOrder(order *domain.Order) error {
 	return nil
 }
 
-func (r *orderRepository) SaveOrderTx(ctx context.Context, order *domain.Order) error {
+func (r *orderRepository) SaveOrderTx(ctx context.Context, order *domain.Order) (err error) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "orderRepository.SaveOrderTx")
+	defer func() {
+		metrics.ObserveSince(metrics.SaveOrderDuration, start, metrics.Status(err))
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	span.SetAttributes(attribute.String("order.uid", order.OrderUID))
+
 	if err := validateOrder(order); err != nil {
-		r.log.Warn("invalid order data, skipping", "order_uid", order.OrderUID, "error", err)
+		r.log.WithContext(ctx).Warn("invalid order data, skipping", "order_uid", order.OrderUID, "error", err)
 		return err
 	}
 
 	transaction, err := r.db.Pool().Begin(ctx)
 	if err != nil {
-		r.log.Error("failed to begin transaction", "order_uid", order.OrderUID, "error", err)
+		r.log.WithContext(ctx).Error("failed to begin transaction", "order_uid", order.OrderUID, "error", err)
 		return fmt.Errorf("begin transaction: %w", err)
 	}
 	defer func() {
 		if rollbackErr := transaction.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) {
-			r.log.Error("failed to rollback transaction", "order_uid", order.OrderUID, "error", rollbackErr)
+			r.log.WithContext(ctx).Error("failed to rollback transaction", "order_uid", order.OrderUID, "error", rollbackErr)
 		}
 	}()
 
@@ -169,28 +187,28 @@ func (r *orderRepository) SaveOrderTx(ctx context.Context, order *domain.Order)
 	}
 
 	if err = transaction.Commit(ctx); err != nil {
-		r.log.Error("failed to commit transaction", "order_uid", order.OrderUID, "error", err)
+		r.log.WithContext(ctx).Error("failed to commit transaction", "order_uid", order.OrderUID, "error", err)
 		return fmt.Errorf("commit transaction: %w", err)
 	}
 
-	r.log.Info("order saved successfully", "order_uid", order.OrderUID, "items_count", len(order.Items))
+	r.log.WithContext(ctx).Info("order saved successfully", "order_uid", order.OrderUID, "items_count", len(order.Items))
 	return nil
 }
 
 func (r *orderRepository) saveOrderInTx(ctx context.Context, transaction Queryable, order *domain.Order) error {
 	rawData, err := json.Marshal(order)
 	if err != nil {
-		r.log.Error("failed to marshal order to JSON", "order_uid", order.OrderUID, "error", err)
+		r.log.WithContext(ctx).Error("failed to marshal order to JSON", "order_uid", order.OrderUID, "error", err)
 		return fmt.Errorf("marshal order to JSON: %w", err)
 	}
 
-	_, err = transaction.Exec(ctx, insertOrderSQL,
+	err = transaction.QueryRow(ctx, insertOrderSQL,
 		order.OrderUID, order.TrackNumber, order.Entry, order.Locale, order.InternalSignature,
 		order.CustomerID, order.DeliveryService, order.Shardkey, order.SmID, order.DateCreated,
 		order.OofShard, rawData,
-	)
+	).Scan(&order.Version, &order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
-		r.log.Error("failed to upsert order", "order_uid", order.OrderUID, "error", err)
+		r.log.WithContext(ctx).Error("failed to upsert order", "order_uid", order.OrderUID, "error", err)
 		return fmt.Errorf("upsert order: %w", err)
 	}
 
@@ -200,7 +218,7 @@ func (r *orderRepository) saveOrderInTx(ctx context.Context, transaction Queryab
 			order.Delivery.City, order.Delivery.Address, order.Delivery.Region, order.Delivery.Email,
 		)
 		if err != nil {
-			r.log.Error("failed to upsert delivery", "order_uid", order.OrderUID, "error", err)
+			r.log.WithContext(ctx).Error("failed to upsert delivery", "order_uid", order.OrderUID, "error", err)
 			return fmt.Errorf("upsert delivery: %w", err)
 		}
 	}
@@ -212,14 +230,14 @@ func (r *orderRepository) saveOrderInTx(ctx context.Context, transaction Queryab
 			order.Payment.DeliveryCost, order.Payment.GoodsTotal, order.Payment.CustomFee,
 		)
 		if err != nil {
-			r.log.Error("failed to upsert payment", "order_uid", order.OrderUID, "error", err)
+			r.log.WithContext(ctx).Error("failed to upsert payment", "order_uid", order.OrderUID, "error", err)
 			return fmt.Errorf("upsert payment: %w", err)
 		}
 	}
 
 	_, err = transaction.Exec(ctx, deleteItemsSQL, order.OrderUID)
 	if err != nil {
-		r.log.Error("failed to delete old items", "order_uid", order.OrderUID, "error", err)
+		r.log.WithContext(ctx).Error("failed to delete old items", "order_uid", order.OrderUID, "error", err)
 		return fmt.Errorf("delete old items: %w", err)
 	}
 
@@ -229,7 +247,10 @@ func (r *orderRepository) saveOrderInTx(ctx context.Context, transaction Queryab
 		}
 	}
 
-	return nil
+	if err := r.enqueueOrderEvents(ctx, transaction, order); err != nil {
+		return err
+	}
+	return r.notifyOrderChanges(ctx, transaction, order)
 }
 
 func (r *orderRepository) insertItemsBatch(ctx context.Context, transaction Queryable, orderUID string, items []domain.Item) error {
@@ -249,14 +270,14 @@ func (r *orderRepository) insertItemsBatch(ctx context.Context, transaction Quer
 	}
 
 	if _, err := transaction.CopyFrom(ctx, pgx.Identifier{"items"}, columns, pgx.CopyFromRows(rows)); err != nil {
-		r.log.Debug("CopyFrom failed, using fallback INSERT", "order_uid", orderUID, "error", err)
+		r.log.WithContext(ctx).Debug("CopyFrom failed, using fallback INSERT", "order_uid", orderUID, "error", err)
 	} else {
 		return nil
 	}
 
 	batchSQL, valueArgs := r.buildBatchInsertSQL(orderUID, items)
 	if _, err := transaction.Exec(ctx, batchSQL, valueArgs...); err != nil {
-		r.log.Error("failed to batch insert items (fallback)", "order_uid", orderUID, "items_count", len(items), "error", err)
+		r.log.WithContext(ctx).Error("failed to batch insert items (fallback)", "order_uid", orderUID, "items_count", len(items), "error", err)
 		return fmt.Errorf("batch insert items: %w", err)
 	}
 
@@ -286,157 +307,275 @@ func (r *orderRepository) buildBatchInsertSQL(orderUID string, items []domain.It
 	return batchSQL, valueArgs
 }
 
-func (r *orderRepository) GetOrder(ctx context.Context, orderUID string) (*domain.Order, error) {
+func (r *orderRepository) GetOrder(ctx context.Context, orderUID string) (_ *domain.Order, err error) {
+	ctx, span := tracer.Start(ctx, "orderRepository.GetOrder", trace.WithAttributes(attribute.String("order.uid", orderUID)))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
 	if orderUID == "" {
 		return nil, ErrEmptyOrderUID
 	}
 
-	var order domain.Order
-	var rawData []byte
-
-	err := r.db.Pool().QueryRow(ctx, selectOrderSQL, orderUID).Scan(
-		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
-		&order.CustomerID, &order.DeliveryService, &order.Shardkey, &order.SmID, &order.DateCreated,
-		&order.OofShard, &rawData, &order.CreatedAt, &order.UpdatedAt,
-	)
+	order, err := scanOrder(r.db.Pool().QueryRow(ctx, selectOrderSQL, orderUID))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			r.log.Debug("order not found", "order_uid", orderUID)
+			r.log.WithContext(ctx).Debug("order not found", "order_uid", orderUID)
 			return nil, fmt.Errorf("%w: %s", ErrOrderNotFound, orderUID)
 		}
-		r.log.Error("failed to get order", "order_uid", orderUID, "error", err)
+		r.log.WithContext(ctx).Error("failed to get order", "order_uid", orderUID, "error", err)
 		return nil, fmt.Errorf("get order: %w", err)
 	}
 
-	order.Raw = rawData
+	r.log.WithContext(ctx).Debug("order retrieved successfully", "order_uid", orderUID, "items_count", len(order.Items))
+	return order, nil
+}
 
-	if delivery, err := r.getDelivery(ctx, orderUID); err != nil {
+func scanOrder(row pgx.Row) (*domain.Order, error) {
+	var (
+		order                             domain.Order
+		rawData, delivery, payment, items []byte
+		paymentTs                         *time.Time
+	)
+
+	err := row.Scan(
+		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
+		&order.CustomerID, &order.DeliveryService, &order.Shardkey, &order.SmID, &order.DateCreated,
+		&order.OofShard, &rawData, &order.Version, &order.CreatedAt, &order.UpdatedAt,
+		&delivery, &payment, &paymentTs, &items,
+	)
+	if err != nil {
 		return nil, err
-	} else if delivery != nil {
-		order.Delivery = delivery
 	}
 
-	if payment, err := r.getPayment(ctx, orderUID); err != nil {
-		return nil, err
-	} else if payment != nil {
-		order.Payment = payment
+	order.Raw = rawData
+
+	if delivery != nil {
+		order.Delivery = &domain.Delivery{OrderUID: order.OrderUID}
+		if err := json.Unmarshal(delivery, order.Delivery); err != nil {
+			return nil, fmt.Errorf("decode delivery: %w", err)
+		}
 	}
 
-	items, err := r.getItems(ctx, orderUID)
-	if err != nil {
-		return nil, err
+	if payment != nil {
+		order.Payment = &domain.Payment{OrderUID: order.OrderUID}
+		if err := json.Unmarshal(payment, order.Payment); err != nil {
+			return nil, fmt.Errorf("decode payment: %w", err)
+		}
+		if paymentTs != nil {
+			order.Payment.PaymentTs = *paymentTs
+		}
+	}
+
+	if err := json.Unmarshal(items, &order.Items); err != nil {
+		return nil, fmt.Errorf("decode items: %w", err)
+	}
+	for i := range order.Items {
+		order.Items[i].OrderUID = order.OrderUID
 	}
-	order.Items = items
 
-	r.log.Debug("order retrieved successfully", "order_uid", orderUID, "items_count", len(items))
 	return &order, nil
 }
 
-func (r *orderRepository) getDelivery(ctx context.Context, orderUID string) (*domain.Delivery, error) {
-	var delivery domain.Delivery
-	err := r.db.Pool().QueryRow(ctx, selectDeliverySQL, orderUID).Scan(
-		&delivery.Name, &delivery.Phone, &delivery.Zip, &delivery.City,
-		&delivery.Address, &delivery.Region, &delivery.Email,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
+func (r *orderRepository) ListRecent(ctx context.Context, limit int) (_ []*domain.Order, err error) {
+	ctx, span := tracer.Start(ctx, "orderRepository.ListRecent", trace.WithAttributes(attribute.Int("limit", limit)))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	orders := make([]*domain.Order, 0, limit)
+	for order, err := range r.IterateRecent(ctx, limit, defaultPageSize) {
+		if err != nil {
+			return nil, fmt.Errorf("list recent orders: %w", err)
 		}
-		r.log.Error("failed to get delivery", "order_uid", orderUID, "error", err)
-		return nil, fmt.Errorf("get delivery: %w", err)
+		orders = append(orders, order)
 	}
 
-	delivery.OrderUID = orderUID
-	return &delivery, nil
+	r.log.WithContext(ctx).Info("recent orders retrieved", "requested", limit, "returned", len(orders))
+	return orders, nil
 }
 
-func (r *orderRepository) getPayment(ctx context.Context, orderUID string) (*domain.Payment, error) {
-	var payment domain.Payment
-	err := r.db.Pool().QueryRow(ctx, selectPaymentSQL, orderUID).Scan(
-		&payment.Transaction, &payment.RequestID, &payment.Currency, &payment.Provider,
-		&payment.Amount, &payment.PaymentDt, &payment.Bank, &payment.DeliveryCost,
-		&payment.GoodsTotal, &payment.CustomFee, &payment.PaymentTs,
-	)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, nil
-		}
-		r.log.Error("failed to get payment", "order_uid", orderUID, "error", err)
-		return nil, fmt.Errorf("get payment: %w", err)
-	}
+// IterateRecent yields up to limit orders (all when limit <= 0), newest first.
+func (r *orderRepository) IterateRecent(ctx context.Context, limit, pageSize int) iter.Seq2[*domain.Order, error] {
+	return r.iterateOrders(ctx, time.Time{}, limit, pageSize)
+}
 
-	payment.OrderUID = orderUID
-	return &payment, nil
+func (r *orderRepository) IterateSince(ctx context.Context, since time.Time, limit, pageSize int) iter.Seq2[*domain.Order, error] {
+	return r.iterateOrders(ctx, since, limit, pageSize)
 }
 
-func (r *orderRepository) getItems(ctx context.Context, orderUID string) ([]domain.Item, error) {
-	rows, err := r.db.Pool().Query(ctx, selectItemsSQL, orderUID)
-	if err != nil {
-		r.log.Error("failed to get items", "order_uid", orderUID, "error", err)
-		return nil, fmt.Errorf("get items: %w", err)
+func (r *orderRepository) iterateOrders(ctx context.Context, since time.Time, limit, pageSize int) iter.Seq2[*domain.Order, error] {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
 	}
-	defer rows.Close()
 
-	var items []domain.Item
-	for rows.Next() {
-		var item domain.Item
-		err := rows.Scan(
-			&item.ChrtID, &item.TrackNumber, &item.Price, &item.RID, &item.Name,
-			&item.Sale, &item.Size, &item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
+	return func(yield func(*domain.Order, error) bool) {
+		var (
+			last    *domain.Order
+			yielded int
 		)
-		if err != nil {
-			r.log.Error("failed to scan item", "order_uid", orderUID, "error", err)
-			return nil, fmt.Errorf("scan item: %w", err)
+
+		for limit <= 0 || yielded < limit {
+			size := pageSize
+			if limit > 0 {
+				size = min(size, limit-yielded)
+			}
+
+			page, err := r.loadOrdersPage(ctx, last, since, size)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, order := range page {
+				if !yield(order, nil) {
+					return
+				}
+			}
+
+			yielded += len(page)
+			if len(page) < size {
+				return
+			}
+			last = page[len(page)-1]
 		}
-		item.OrderUID = orderUID
-		items = append(items, item)
+	}
+}
+
+func (r *orderRepository) loadOrdersPage(ctx context.Context, after *domain.Order, since time.Time, size int) (_ []*domain.Order, err error) {
+	ctx, span := tracer.Start(ctx, "orderRepository.loadOrdersPage", trace.WithAttributes(attribute.Int("page.size", size)))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	query, args := buildOrdersPageSQL(after, since, size)
+	rows, err := r.db.Pool().Query(ctx, query, args...)
+	if err != nil {
+		r.log.WithContext(ctx).Error("failed to load orders page", "size", size, "error", err)
+		return nil, fmt.Errorf("load orders page: %w", err)
 	}
 
-	if err := rows.Err(); err != nil {
-		r.log.Error("failed to iterate items", "order_uid", orderUID, "error", err)
-		return nil, fmt.Errorf("iterate items: %w", err)
+	page, err := collectOrders(rows, size)
+	if err != nil {
+		r.log.WithContext(ctx).Error("failed to read orders page", "error", err)
+		return nil, fmt.Errorf("read orders page: %w", err)
 	}
 
-	return items, nil
+	span.SetAttributes(attribute.Int("page.rows", len(page)))
+	return page, nil
 }
 
-func (r *orderRepository) ListRecent(ctx context.Context, limit int) ([]*domain.Order, error) {
-	if limit <= 0 {
-		limit = 100
+func buildOrdersPageSQL(after *domain.Order, since time.Time, size int) (string, []any) {
+	var (
+		conditions []string
+		args       = []any{size}
+	)
+
+	if after != nil {
+		args = append(args, after.CreatedAt, after.OrderUID)
+		conditions = append(conditions, fmt.Sprintf("(o.created_at, o.order_uid) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+	if !since.IsZero() {
+		args = append(args, since)
+		conditions = append(conditions, fmt.Sprintf("o.date_created >= $%d", len(args)))
 	}
 
-	rows, err := r.db.Pool().Query(ctx, selectRecentOrderUIDsSQL, limit)
-	if err != nil {
-		r.log.Error("failed to get recent order UIDs", "limit", limit, "error", err)
-		return nil, fmt.Errorf("get recent order UIDs: %w", err)
+	var query strings.Builder
+	query.WriteString(selectOrderColumnsSQL)
+	if len(conditions) > 0 {
+		query.WriteString("\n        WHERE ")
+		query.WriteString(strings.Join(conditions, " AND "))
 	}
+	query.WriteString("\n        ORDER BY o.created_at DESC, o.order_uid DESC\n        LIMIT $1")
+
+	return query.String(), args
+}
+
+func collectOrders(rows pgx.Rows, capacity int) ([]*domain.Order, error) {
 	defer rows.Close()
 
-	var orderUIDs []string
+	orders := make([]*domain.Order, 0, capacity)
 	for rows.Next() {
-		var orderUID string
-		if err := rows.Scan(&orderUID); err != nil {
-			r.log.Error("failed to scan order UID", "error", err)
-			return nil, fmt.Errorf("scan order UID: %w", err)
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan order: %w", err)
 		}
-		orderUIDs = append(orderUIDs, orderUID)
+		orders = append(orders, order)
 	}
 
 	if err := rows.Err(); err != nil {
-		r.log.Error("failed to iterate order UIDs", "error", err)
-		return nil, fmt.Errorf("iterate order UIDs: %w", err)
+		return nil, fmt.Errorf("iterate orders: %w", err)
 	}
+	return orders, nil
+}
 
-	var orders []*domain.Order
-	for _, orderUID := range orderUIDs {
-		order, err := r.GetOrder(ctx, orderUID)
+// IterateChangesSince yields the UID and version of every order updated since.
+func (r *orderRepository) IterateChangesSince(ctx context.Context, since time.Time) iter.Seq2[domain.OrderChange, error] {
+	return func(yield func(domain.OrderChange, error) bool) {
+		ctx, span := tracer.Start(ctx, "orderRepository.IterateChangesSince", trace.WithAttributes(attribute.String("since", since.Format(time.RFC3339))))
+		defer span.End()
+
+		rows, err := r.db.Pool().Query(ctx, selectChangedOrdersSQL, since)
 		if err != nil {
-			r.log.Warn("failed to get order during list recent", "order_uid", orderUID, "error", err)
-			continue
+			tracing.RecordError(span, err)
+			r.log.WithContext(ctx).Error("failed to query changed orders", "since", since, "error", err)
+			yield(domain.OrderChange{}, fmt.Errorf("query changed orders: %w", err))
+			return
 		}
-		orders = append(orders, order)
+		defer rows.Close()
+
+		changes := 0
+		for rows.Next() {
+			var change domain.OrderChange
+			if err := rows.Scan(&change.OrderUID, &change.Version); err != nil {
+				tracing.RecordError(span, err)
+				yield(domain.OrderChange{}, fmt.Errorf("scan changed order: %w", err))
+				return
+			}
+			changes++
+			if !yield(change, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			tracing.RecordError(span, err)
+			r.log.WithContext(ctx).Error("failed to read changed orders", "since", since, "error", err)
+			yield(domain.OrderChange{}, fmt.Errorf("read changed orders: %w", err))
+			return
+		}
+		span.SetAttributes(attribute.Int("changes", changes))
+	}
+}
+
+// GetOrders loads the given orders in one query; unknown UIDs are skipped.
+func (r *orderRepository) GetOrders(ctx context.Context, orderUIDs []string) (_ []*domain.Order, err error) {
+	ctx, span := tracer.Start(ctx, "orderRepository.GetOrders", trace.WithAttributes(attribute.Int("order.count", len(orderUIDs))))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	if len(orderUIDs) == 0 {
+		return nil, nil
+	}
+
+	rows, err := r.db.Pool().Query(ctx, selectOrdersByUIDsSQL, orderUIDs)
+	if err != nil {
+		r.log.WithContext(ctx).Error("failed to get orders", "count", len(orderUIDs), "error", err)
+		return nil, fmt.Errorf("get orders: %w", err)
 	}
 
-	r.log.Info("recent orders retrieved", "requested", limit, "found", len(orderUIDs), "returned", len(orders))
+	orders, err := collectOrders(rows, len(orderUIDs))
+	if err != nil {
+		r.log.WithContext(ctx).Error("failed to read orders", "count", len(orderUIDs), "error", err)
+		return nil, fmt.Errorf("read orders: %w", err)
+	}
 	return orders, nil
 }
@@ -0,0 +1,173 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/db/postgres/connect"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// benchDSNEnv names a migrated database the benchmarks may write to.
+const benchDSNEnv = "BENCH_POSTGRES_DSN"
+
+// The multi-query read GetOrder used before it switched to selectOrderSQL.
+const (
+	benchSelectOrderRowSQL = `
+        SELECT order_uid, track_number, entry, locale, internal_signature, customer_id,
+               delivery_service, shardkey, sm_id, date_created, oof_shard, raw, version, created_at, updated_at
+        FROM orders
+        WHERE order_uid = $1`
+
+	benchSelectDeliverySQL = `
+        SELECT name, phone, zip, city, address, region, email
+        FROM delivery
+        WHERE order_uid = $1`
+
+	benchSelectPaymentSQL = `
+        SELECT transaction, request_id, currency, provider, amount, payment_dt,
+               bank, delivery_cost, goods_total, custom_fee, payment_ts
+        FROM payment
+        WHERE order_uid = $1`
+
+	benchSelectItemsSQL = `
+        SELECT chrt_id, track_number, price, rid, name, sale, size,
+               total_price, nm_id, brand, status
+        FROM items
+        WHERE order_uid = $1
+        ORDER BY id`
+)
+
+func newBenchRepository(b *testing.B) (*orderRepository, *connect.DB) {
+	b.Helper()
+
+	dsn := os.Getenv(benchDSNEnv)
+	if dsn == "" {
+		b.Skipf("%s is not set", benchDSNEnv)
+	}
+
+	db, err := connect.NewPool(b.Context(), dsn, 4, 1, time.Hour, time.Minute, 5*time.Second)
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+	b.Cleanup(db.Close)
+
+	zapLogger := zap.NewNop()
+	log := &logger.ZapLogger{Logger: zapLogger, Sugared: zapLogger.Sugar()}
+	return &orderRepository{db: db, log: log, outbox: true}, db
+}
+
+func seedBenchOrder(b *testing.B, repo *orderRepository, db *connect.DB, items int) string {
+	b.Helper()
+
+	orderUID := fmt.Sprintf("bench-%d-%d", items, time.Now().UnixNano())
+	order := &domain.Order{
+		OrderUID:        orderUID,
+		TrackNumber:     "BENCHTRACK",
+		Entry:           "WBIL",
+		Locale:          "en",
+		CustomerID:      "bench",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmID:            99,
+		DateCreated:     time.Now().UTC(),
+		OofShard:        "1",
+		Raw:             []byte(`{}`),
+		Delivery:        &domain.Delivery{Name: "Bench", Phone: "+9720000000", City: "Kiryat Mozkin", Email: "bench@example.com"},
+		Payment:         &domain.Payment{Transaction: orderUID, Currency: "USD", Provider: "wbpay", Amount: 1817, PaymentDt: 1637907727},
+	}
+	for i := range items {
+		order.Items = append(order.Items, domain.Item{
+			ChrtID: int64(i), TrackNumber: "BENCHTRACK", RID: fmt.Sprintf("%s-%d", orderUID, i), Name: "item", NmID: int64(i), Price: 100, TotalPrice: 100,
+		})
+	}
+
+	if err := repo.SaveOrderTx(b.Context(), order); err != nil {
+		b.Fatalf("seed order: %v", err)
+	}
+	b.Cleanup(func() {
+		_, _ = db.Pool().Exec(context.Background(), `DELETE FROM orders WHERE order_uid = $1`, orderUID)
+	})
+	return orderUID
+}
+
+func getOrderMultiQuery(ctx context.Context, db *connect.DB, orderUID string) (*domain.Order, error) {
+	order := domain.Order{Delivery: &domain.Delivery{}, Payment: &domain.Payment{}}
+	err := db.Pool().QueryRow(ctx, benchSelectOrderRowSQL, orderUID).Scan(
+		&order.OrderUID, &order.TrackNumber, &order.Entry, &order.Locale, &order.InternalSignature,
+		&order.CustomerID, &order.DeliveryService, &order.Shardkey, &order.SmID, &order.DateCreated,
+		&order.OofShard, &order.Raw, &order.Version, &order.CreatedAt, &order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get order: %w", err)
+	}
+
+	err = db.Pool().QueryRow(ctx, benchSelectDeliverySQL, orderUID).Scan(
+		&order.Delivery.Name, &order.Delivery.Phone, &order.Delivery.Zip, &order.Delivery.City,
+		&order.Delivery.Address, &order.Delivery.Region, &order.Delivery.Email,
+	)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("get delivery: %w", err)
+	}
+
+	err = db.Pool().QueryRow(ctx, benchSelectPaymentSQL, orderUID).Scan(
+		&order.Payment.Transaction, &order.Payment.RequestID, &order.Payment.Currency, &order.Payment.Provider,
+		&order.Payment.Amount, &order.Payment.PaymentDt, &order.Payment.Bank, &order.Payment.DeliveryCost,
+		&order.Payment.GoodsTotal, &order.Payment.CustomFee, &order.Payment.PaymentTs,
+	)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("get payment: %w", err)
+	}
+
+	rows, err := db.Pool().Query(ctx, benchSelectItemsSQL, orderUID)
+	if err != nil {
+		return nil, fmt.Errorf("get items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item domain.Item
+		if err := rows.Scan(
+			&item.ChrtID, &item.TrackNumber, &item.Price, &item.RID, &item.Name,
+			&item.Sale, &item.Size, &item.TotalPrice, &item.NmID, &item.Brand, &item.Status,
+		); err != nil {
+			return nil, fmt.Errorf("scan item: %w", err)
+		}
+		order.Items = append(order.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate items: %w", err)
+	}
+	return &order, nil
+}
+
+func BenchmarkGetOrder(b *testing.B) {
+	repo, db := newBenchRepository(b)
+
+	for _, items := range []int{1, 10, 100} {
+		orderUID := seedBenchOrder(b, repo, db, items)
+
+		b.Run(fmt.Sprintf("multi_query/items=%d", items), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := getOrderMultiQuery(b.Context(), db, orderUID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("single_query/items=%d", items), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := repo.GetOrder(b.Context(), orderUID); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
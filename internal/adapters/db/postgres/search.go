@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sortColumns maps sort fields to columns; rows with a NULL one are skipped.
+var sortColumns = map[domain.OrderSortField]string{
+	domain.SortByDateCreated: "o.date_created",
+	domain.SortByCreatedAt:   "o.created_at",
+}
+
+// SearchOrders reads one extra row to tell whether a next page exists.
+func (r *orderRepository) SearchOrders(ctx context.Context, search domain.OrderSearch) (_ *domain.OrderPage, err error) {
+	ctx, span := tracer.Start(ctx, "orderRepository.SearchOrders", trace.WithAttributes(
+		attribute.String("search.sort", search.Sort.String()),
+		attribute.Bool("search.continued", search.After != nil),
+		attribute.Int("limit", search.Limit),
+	))
+	defer func() {
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	if err := search.Validate(); err != nil {
+		return nil, err
+	}
+
+	query, args := buildSearchOrdersSQL(search)
+	rows, err := r.db.Pool().Query(ctx, query, args...)
+	if err != nil {
+		r.log.WithContext(ctx).Error("failed to search orders", "sort", search.Sort, "error", err)
+		return nil, fmt.Errorf("search orders: %w", err)
+	}
+
+	orders, err := collectOrders(rows, search.Limit+1)
+	if err != nil {
+		r.log.WithContext(ctx).Error("failed to read searched orders", "error", err)
+		return nil, fmt.Errorf("read searched orders: %w", err)
+	}
+
+	page := &domain.OrderPage{Orders: orders}
+	if len(orders) > search.Limit {
+		page.Orders = orders[:search.Limit]
+		page.Next = domain.NewOrderCursor(search.Sort, page.Orders[search.Limit-1])
+	}
+
+	span.SetAttributes(attribute.Int("page.rows", len(page.Orders)))
+	r.log.WithContext(ctx).Debug("orders searched", "sort", search.Sort, "orders", len(page.Orders), "more", page.Next != nil)
+	return page, nil
+}
+
+func buildSearchOrdersSQL(search domain.OrderSearch) (string, []any) {
+	var (
+		conditions []string
+		args       = []any{search.Limit + 1}
+	)
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	equal := func(column, value string) {
+		if value != "" {
+			conditions = append(conditions, column+" = "+arg(value))
+		}
+	}
+
+	column := sortColumns[search.Sort.Field]
+	conditions = append(conditions, column+" IS NOT NULL")
+
+	filter := search.Filter
+	equal("o.customer_id", filter.CustomerID)
+	equal("o.track_number", filter.TrackNumber)
+	equal("o.delivery_service", filter.DeliveryService)
+	equal("o.locale", filter.Locale)
+	equal("p.provider", filter.PaymentProvider)
+	equal("p.currency", filter.PaymentCurrency)
+	if !filter.DateFrom.IsZero() {
+		conditions = append(conditions, "o.date_created >= "+arg(filter.DateFrom))
+	}
+	if !filter.DateTo.IsZero() {
+		conditions = append(conditions, "o.date_created < "+arg(filter.DateTo))
+	}
+
+	var itemConditions []string
+	if filter.ItemNmID != 0 {
+		itemConditions = append(itemConditions, "it.nm_id = "+arg(filter.ItemNmID))
+	}
+	if filter.ItemBrand != "" {
+		itemConditions = append(itemConditions, "it.brand = "+arg(filter.ItemBrand))
+	}
+	if len(itemConditions) > 0 {
+		conditions = append(conditions, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM items it WHERE it.order_uid = o.order_uid AND %s)",
+			strings.Join(itemConditions, " AND ")))
+	}
+
+	direction, comparison := "ASC", ">"
+	if search.Sort.Desc {
+		direction, comparison = "DESC", "<"
+	}
+	if search.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, o.order_uid) %s (%s, %s)",
+			column, comparison, arg(search.After.Value), arg(search.After.OrderUID)))
+	}
+
+	var query strings.Builder
+	query.WriteString(selectOrderColumnsSQL)
+	query.WriteString("\n        WHERE ")
+	query.WriteString(strings.Join(conditions, " AND "))
+	fmt.Fprintf(&query, "\n        ORDER BY %s %s, o.order_uid %s\n        LIMIT $1", column, direction, direction)
+
+	return query.String(), args
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+)
+
+// whereClause returns the query after the order columns, whitespace collapsed.
+func whereClause(t *testing.T, query string) string {
+	t.Helper()
+
+	rest, found := strings.CutPrefix(query, selectOrderColumnsSQL)
+	if !found {
+		t.Fatalf("query does not select the order columns:\n%s", query)
+	}
+	where, found := strings.CutPrefix(strings.Join(strings.Fields(rest), " "), "WHERE ")
+	if !found {
+		t.Fatalf("query has no WHERE clause:\n%s", query)
+	}
+	return where
+}
+
+func TestBuildSearchOrdersSQL(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	after := &domain.OrderCursor{Value: from.Add(time.Hour), OrderUID: "b563feb7b2b84b6test"}
+
+	tests := []struct {
+		name      string
+		search    domain.OrderSearch
+		wantWhere string
+		wantArgs  []any
+	}{
+		{
+			name:      "default sort without filters",
+			search:    domain.OrderSearch{Sort: domain.DefaultOrderSort, Limit: 20},
+			wantWhere: "o.date_created IS NOT NULL ORDER BY o.date_created DESC, o.order_uid DESC LIMIT $1",
+			wantArgs:  []any{21},
+		},
+		{
+			name: "order and payment filters",
+			search: domain.OrderSearch{
+				Sort:  domain.OrderSort{Field: domain.SortByCreatedAt},
+				Limit: 10,
+				Filter: domain.OrderFilter{
+					CustomerID: "test", PaymentCurrency: "USD", DateFrom: from, DateTo: to,
+				},
+			},
+			wantWhere: "o.created_at IS NOT NULL AND o.customer_id = $2 AND p.currency = $3 AND o.date_created >= $4" +
+				" AND o.date_created < $5 ORDER BY o.created_at ASC, o.order_uid ASC LIMIT $1",
+			wantArgs: []any{11, "test", "USD", from, to},
+		},
+		{
+			name: "item filters match one item",
+			search: domain.OrderSearch{
+				Sort:   domain.DefaultOrderSort,
+				Limit:  5,
+				Filter: domain.OrderFilter{ItemNmID: 2389212, ItemBrand: "Vivienne Sabo"},
+			},
+			wantWhere: "o.date_created IS NOT NULL AND EXISTS (SELECT 1 FROM items it WHERE it.order_uid = o.order_uid" +
+				" AND it.nm_id = $2 AND it.brand = $3) ORDER BY o.date_created DESC, o.order_uid DESC LIMIT $1",
+			wantArgs: []any{6, int64(2389212), "Vivienne Sabo"},
+		},
+		{
+			name:      "descending continuation",
+			search:    domain.OrderSearch{Sort: domain.DefaultOrderSort, Limit: 20, After: after},
+			wantWhere: "o.date_created IS NOT NULL AND (o.date_created, o.order_uid) < ($2, $3) ORDER BY o.date_created DESC, o.order_uid DESC LIMIT $1",
+			wantArgs:  []any{21, after.Value, after.OrderUID},
+		},
+		{
+			name:      "ascending continuation",
+			search:    domain.OrderSearch{Sort: domain.OrderSort{Field: domain.SortByCreatedAt}, Limit: 20, After: after},
+			wantWhere: "o.created_at IS NOT NULL AND (o.created_at, o.order_uid) > ($2, $3) ORDER BY o.created_at ASC, o.order_uid ASC LIMIT $1",
+			wantArgs:  []any{21, after.Value, after.OrderUID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := buildSearchOrdersSQL(tt.search)
+
+			if got := whereClause(t, query); got != tt.wantWhere {
+				t.Fatalf("WHERE clause =\n  %s\nwant\n  %s", got, tt.wantWhere)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Fatalf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
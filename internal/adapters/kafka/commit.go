@@ -0,0 +1,161 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/segmentio/kafka-go"
+)
+
+// Commit strategies: sync and async commit an offset once it and every earlier
+// offset of its partition were processed (at-least-once); auto is at-most-once.
+const (
+	CommitStrategySync  = "sync"
+	CommitStrategyAsync = "async"
+	CommitStrategyAuto  = "auto"
+)
+
+var ErrInvalidCommitInterval = errors.New("kafka.commit_interval must be positive with the async commit strategy")
+
+func ResolveCommitStrategy(cfg config.KafkaConfig) string {
+	if cfg.EnableAutoCommit {
+		return CommitStrategyAuto
+	}
+
+	switch cfg.CommitStrategy {
+	case CommitStrategyAsync, CommitStrategyAuto:
+		return cfg.CommitStrategy
+	default:
+		return CommitStrategySync
+	}
+}
+
+type messageCommitter interface {
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type offsetCommitter struct {
+	lastFailure atomic.Value
+	reader      messageCommitter
+	log         logger.Logger
+	committed   map[topicPartition]int64
+	pending     map[topicPartition]kafka.Message
+	strategy    string
+	commits     atomic.Uint64
+	messages    atomic.Uint64
+	failures    atomic.Uint64
+	mu          sync.Mutex
+}
+
+func newOffsetCommitter(reader messageCommitter, strategy string, log logger.Logger) *offsetCommitter {
+	return &offsetCommitter{
+		reader:    reader,
+		log:       log,
+		committed: make(map[topicPartition]int64),
+		pending:   make(map[topicPartition]kafka.Message),
+		strategy:  strategy,
+	}
+}
+
+func (oc *offsetCommitter) Commit(ctx context.Context, msgs ...kafka.Message) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	fresh := make([]kafka.Message, 0, len(msgs))
+	for _, msg := range msgs {
+		if committed, exists := oc.committed[partitionOf(msg)]; exists && committed >= msg.Offset {
+			continue
+		}
+		if queued, exists := oc.pending[partitionOf(msg)]; exists && queued.Offset >= msg.Offset {
+			continue
+		}
+		fresh = append(fresh, msg)
+	}
+
+	switch oc.strategy {
+	case CommitStrategyAuto:
+		return
+	case CommitStrategyAsync:
+		for _, msg := range fresh {
+			oc.pending[partitionOf(msg)] = msg
+		}
+	default:
+		oc.commitLocked(ctx, fresh)
+	}
+}
+
+func (oc *offsetCommitter) Flush(ctx context.Context) {
+	oc.mu.Lock()
+	defer oc.mu.Unlock()
+
+	if len(oc.pending) == 0 {
+		return
+	}
+
+	msgs := make([]kafka.Message, 0, len(oc.pending))
+	for _, msg := range oc.pending {
+		msgs = append(msgs, msg)
+	}
+
+	if oc.commitLocked(ctx, msgs) {
+		clear(oc.pending)
+	}
+}
+
+func (oc *offsetCommitter) Run(ctx context.Context, interval time.Duration) {
+	if oc.strategy != CommitStrategyAsync {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			oc.Flush(ctx)
+		}
+	}
+}
+
+func (oc *offsetCommitter) Stats() domain.CommitStats {
+	stats := domain.CommitStats{
+		Strategy:          oc.strategy,
+		Commits:           oc.commits.Load(),
+		CommittedMessages: oc.messages.Load(),
+		Failures:          oc.failures.Load(),
+	}
+	if lastFailure, ok := oc.lastFailure.Load().(time.Time); ok {
+		stats.LastFailure = lastFailure
+	}
+	return stats
+}
+
+func (oc *offsetCommitter) commitLocked(ctx context.Context, msgs []kafka.Message) bool {
+	if len(msgs) == 0 {
+		return true
+	}
+
+	if err := oc.reader.CommitMessages(ctx, msgs...); err != nil {
+		oc.failures.Add(1)
+		oc.lastFailure.Store(time.Now())
+		oc.log.Error("failed to commit messages", "strategy", oc.strategy, "messages", len(msgs), "error", err)
+		return false
+	}
+
+	oc.commits.Add(1)
+	oc.messages.Add(uint64(len(msgs)))
+	for _, msg := range msgs {
+		oc.committed[partitionOf(msg)] = msg.Offset
+		oc.log.Debug("message committed", "topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset)
+	}
+	return true
+}
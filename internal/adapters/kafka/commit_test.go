@@ -0,0 +1,451 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/retry"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+)
+
+const testTopic = "orders"
+
+var (
+	errCommitUnavailable = errors.New("coordinator not available")
+	errHandlerFailed     = errors.New("handler failed")
+)
+
+type fakeCommitter struct {
+	committed []kafka.Message
+	failures  int
+	calls     int
+	mu        sync.Mutex
+}
+
+func (f *fakeCommitter) CommitMessages(_ context.Context, msgs ...kafka.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if f.failures > 0 {
+		f.failures--
+		return errCommitUnavailable
+	}
+	f.committed = append(f.committed, msgs...)
+	return nil
+}
+
+func (f *fakeCommitter) offsets() []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	offsets := make([]int64, 0, len(f.committed))
+	for _, msg := range f.committed {
+		offsets = append(offsets, msg.Offset)
+	}
+	return offsets
+}
+
+type handlerFunc func(ctx context.Context, msg *domain.Message) error
+
+func (f handlerFunc) HandleMessage(ctx context.Context, msg *domain.Message) error {
+	return f(ctx, msg)
+}
+
+func nopLogger() logger.Logger {
+	zapLogger := zap.NewNop()
+	return &logger.ZapLogger{Logger: zapLogger, Sugared: zapLogger.Sugar()}
+}
+
+func newTestConsumer(t *testing.T, strategy string, commits *fakeCommitter, handler ports.MessageHandler) *kafkaConsumer {
+	t.Helper()
+
+	return &kafkaConsumer{
+		retry:     retry.NewPolicy(config.RetryConfig{MaxAttempts: 1}, isRetryableProcessingError),
+		routes:    map[string]ports.MessageHandler{testTopic: handler},
+		log:       nopLogger(),
+		offsets:   newOffsetTracker(),
+		committer: newOffsetCommitter(commits, strategy, nopLogger()),
+		workers:   1,
+		batchSize: 1,
+	}
+}
+
+func testMessage(offset int64) kafka.Message {
+	return kafka.Message{Topic: testTopic, Partition: 0, Offset: offset, Value: []byte(`{}`)}
+}
+
+func TestResolveCommitStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.KafkaConfig
+		want string
+	}{
+		{name: "default", cfg: config.KafkaConfig{}, want: CommitStrategySync},
+		{name: "async", cfg: config.KafkaConfig{CommitStrategy: "async"}, want: CommitStrategyAsync},
+		{name: "auto", cfg: config.KafkaConfig{CommitStrategy: "auto"}, want: CommitStrategyAuto},
+		{name: "unknown", cfg: config.KafkaConfig{CommitStrategy: "eventually"}, want: CommitStrategySync},
+		{name: "enable_auto_commit", cfg: config.KafkaConfig{CommitStrategy: "sync", EnableAutoCommit: true}, want: CommitStrategyAuto},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveCommitStrategy(tt.cfg); got != tt.want {
+				t.Errorf("ResolveCommitStrategy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsyncCommitRequiresPositiveInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		cfg := config.KafkaConfig{CommitStrategy: CommitStrategyAsync, CommitInterval: interval}
+		if _, err := NewKafkaConsumerWithConfig(cfg, config.RetryConfig{}, nil, nopLogger()); !errors.Is(err, ErrInvalidCommitInterval) {
+			t.Fatalf("NewKafkaConsumerWithConfig() with commit_interval %s error = %v, want ErrInvalidCommitInterval", interval, err)
+		}
+	}
+}
+
+func TestSyncCommitWaitsForHandler(t *testing.T) {
+	commits := &fakeCommitter{}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	consumer := newTestConsumer(t, CommitStrategySync, commits, handlerFunc(func(context.Context, *domain.Message) error {
+		close(started)
+		<-release
+		return nil
+	}))
+
+	msg := testMessage(10)
+	consumer.offsets.Track(msg)
+	queue := make(chan kafka.Message, 1)
+	queue <- msg
+	close(queue)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		consumer.runWorker(t.Context(), 0, queue)
+	}()
+
+	<-started
+	if offsets := commits.offsets(); len(offsets) != 0 {
+		t.Fatalf("committed %v while the handler was still running", offsets)
+	}
+
+	close(release)
+	<-done
+
+	if offsets := commits.offsets(); !slices.Equal(offsets, []int64{10}) {
+		t.Fatalf("committed %v, want [10]", offsets)
+	}
+}
+
+func TestSyncCommitWaitsForEarlierOffsets(t *testing.T) {
+	commits := &fakeCommitter{}
+	release := make(chan struct{})
+	consumer := newTestConsumer(t, CommitStrategySync, commits, handlerFunc(func(_ context.Context, msg *domain.Message) error {
+		if msg.Offset == 1 {
+			<-release
+		}
+		return nil
+	}))
+
+	first, second := testMessage(1), testMessage(2)
+	consumer.offsets.Track(first)
+	consumer.offsets.Track(second)
+
+	slow := make(chan kafka.Message, 1)
+	fast := make(chan kafka.Message, 1)
+	slow <- first
+	fast <- second
+	close(slow)
+	close(fast)
+
+	var workers sync.WaitGroup
+	workers.Go(func() { consumer.runWorker(t.Context(), 0, slow) })
+	consumer.runWorker(t.Context(), 1, fast)
+
+	if offsets := commits.offsets(); len(offsets) != 0 {
+		t.Fatalf("committed %v before offset 1 was processed", offsets)
+	}
+
+	close(release)
+	workers.Wait()
+
+	if offsets := commits.offsets(); !slices.Equal(offsets, []int64{2}) {
+		t.Fatalf("committed %v, want [2]", offsets)
+	}
+}
+
+type fakeWriter struct {
+	written  []kafka.Message
+	failures int
+	mu       sync.Mutex
+}
+
+func (f *fakeWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failures > 0 {
+		f.failures--
+		return errCommitUnavailable
+	}
+	f.written = append(f.written, msgs...)
+	return nil
+}
+
+func (f *fakeWriter) Close() error {
+	return nil
+}
+
+func failFirstMessage(_ context.Context, msg *domain.Message) error {
+	if msg.Offset == 1 {
+		return errHandlerFailed
+	}
+	return nil
+}
+
+func runTestMessages(t *testing.T, consumer *kafkaConsumer, offsets ...int64) {
+	t.Helper()
+
+	queue := make(chan kafka.Message, len(offsets))
+	for _, offset := range offsets {
+		msg := testMessage(offset)
+		consumer.offsets.Track(msg)
+		queue <- msg
+	}
+	close(queue)
+
+	consumer.runWorker(t.Context(), 0, queue)
+}
+
+func TestDeadLetteredMessageDoesNotBlockPartition(t *testing.T) {
+	commits := &fakeCommitter{}
+	writer := &fakeWriter{failures: 1}
+	consumer := newTestConsumer(t, CommitStrategySync, commits, handlerFunc(failFirstMessage))
+	consumer.dlq = &deadLetterWriter{writer: writer, retry: retry.NewPolicy(config.RetryConfig{MaxAttempts: 2}, nil), log: nopLogger()}
+
+	runTestMessages(t, consumer, 1, 2)
+
+	if len(writer.written) != 1 || !hasHeader(writer.written[0].Headers, HeaderDLQOriginalOffset) {
+		t.Fatalf("dead-lettered %v, want the failed message after one retry", writer.written)
+	}
+	if offsets := commits.offsets(); !slices.Equal(offsets, []int64{1, 2}) {
+		t.Fatalf("committed %v, want [1 2]", offsets)
+	}
+}
+
+func TestUndeliverableMessageHaltsPartition(t *testing.T) {
+	tests := []struct {
+		name string
+		dlq  *deadLetterWriter
+	}{
+		{name: "dead-letter send fails", dlq: &deadLetterWriter{
+			writer: &fakeWriter{failures: 2},
+			retry:  retry.NewPolicy(config.RetryConfig{MaxAttempts: 2}, nil),
+			log:    nopLogger(),
+		}},
+		{name: "dead-letter topic disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commits := &fakeCommitter{}
+			var handled []int64
+			consumer := newTestConsumer(t, CommitStrategySync, commits, handlerFunc(func(ctx context.Context, msg *domain.Message) error {
+				handled = append(handled, msg.Offset)
+				return failFirstMessage(ctx, msg)
+			}))
+			consumer.dlq = tt.dlq
+
+			runTestMessages(t, consumer, 0, 1, 2)
+
+			if offsets := commits.offsets(); !slices.Equal(offsets, []int64{0}) {
+				t.Fatalf("committed %v, want only [0] before the undeliverable message", offsets)
+			}
+			if !slices.Equal(handled, []int64{0, 1}) {
+				t.Fatalf("handled %v, want the partition halted after offset 1", handled)
+			}
+
+			consumer.offsets.Track(testMessage(1))
+			if consumer.offsets.Halted(testMessage(1)) {
+				t.Fatal("partition still halted after the message was redelivered")
+			}
+		})
+	}
+}
+
+func TestRejectedMessageSkippedWithoutDeadLetterTopic(t *testing.T) {
+	commits := &fakeCommitter{}
+	consumer := newTestConsumer(t, CommitStrategySync, commits, handlerFunc(func(_ context.Context, msg *domain.Message) error {
+		if msg.Offset == 1 {
+			return domain.NewRejectedError(domain.ReasonInvalidJSON, errHandlerFailed)
+		}
+		return nil
+	}))
+
+	runTestMessages(t, consumer, 1, 2)
+
+	if offsets := commits.offsets(); !slices.Equal(offsets, []int64{1, 2}) {
+		t.Fatalf("committed %v, want [1 2]", offsets)
+	}
+}
+
+func TestForcedStopLeavesOffsetUncommitted(t *testing.T) {
+	commits := &fakeCommitter{}
+	ctx, cancel := context.WithCancel(t.Context())
+	consumer := newTestConsumer(t, CommitStrategySync, commits, handlerFunc(func(ctx context.Context, _ *domain.Message) error {
+		cancel()
+		return ctx.Err()
+	}))
+
+	msg := testMessage(7)
+	consumer.offsets.Track(msg)
+	queue := make(chan kafka.Message, 1)
+	queue <- msg
+	close(queue)
+
+	consumer.runWorker(ctx, 0, queue)
+
+	if offsets := commits.offsets(); len(offsets) != 0 {
+		t.Fatalf("committed %v for a message interrupted by shutdown", offsets)
+	}
+}
+
+func TestSyncCommitFailureIsSurfaced(t *testing.T) {
+	commits := &fakeCommitter{failures: 1}
+	committer := newOffsetCommitter(commits, CommitStrategySync, nopLogger())
+
+	committer.Commit(t.Context(), testMessage(1))
+
+	stats := committer.Stats()
+	if stats.Failures != 1 || stats.Commits != 0 || stats.LastFailure.IsZero() {
+		t.Fatalf("stats after failed commit = %+v, want one failure with its time", stats)
+	}
+
+	committer.Commit(t.Context(), testMessage(2))
+
+	stats = committer.Stats()
+	if stats.Failures != 1 || stats.Commits != 1 || stats.CommittedMessages != 1 {
+		t.Fatalf("stats after next commit = %+v, want one failure and one commit", stats)
+	}
+	if offsets := commits.offsets(); !slices.Equal(offsets, []int64{2}) {
+		t.Fatalf("committed %v, want [2]", offsets)
+	}
+}
+
+func TestAsyncCommitWaitsForFlush(t *testing.T) {
+	commits := &fakeCommitter{}
+	consumer := newTestConsumer(t, CommitStrategyAsync, commits, handlerFunc(func(context.Context, *domain.Message) error {
+		return nil
+	}))
+
+	queue := make(chan kafka.Message, 3)
+	for offset := range int64(3) {
+		msg := testMessage(offset)
+		consumer.offsets.Track(msg)
+		queue <- msg
+	}
+	close(queue)
+
+	consumer.runWorker(t.Context(), 0, queue)
+
+	if offsets := commits.offsets(); len(offsets) != 0 {
+		t.Fatalf("committed %v before the flush", offsets)
+	}
+
+	consumer.committer.Flush(t.Context())
+
+	if offsets := commits.offsets(); !slices.Equal(offsets, []int64{2}) {
+		t.Fatalf("committed %v, want only the latest offset [2]", offsets)
+	}
+}
+
+func TestAsyncCommitRetriesFailedFlush(t *testing.T) {
+	commits := &fakeCommitter{failures: 1}
+	committer := newOffsetCommitter(commits, CommitStrategyAsync, nopLogger())
+
+	committer.Commit(t.Context(), testMessage(5))
+	committer.Flush(t.Context())
+
+	if stats := committer.Stats(); stats.Failures != 1 {
+		t.Fatalf("failures = %d, want 1", stats.Failures)
+	}
+	if offsets := commits.offsets(); len(offsets) != 0 {
+		t.Fatalf("committed %v although the commit failed", offsets)
+	}
+
+	committer.Flush(t.Context())
+
+	if offsets := commits.offsets(); !slices.Equal(offsets, []int64{5}) {
+		t.Fatalf("committed %v after the retried flush, want [5]", offsets)
+	}
+}
+
+func TestAsyncCommitFlushesPeriodically(t *testing.T) {
+	commits := &fakeCommitter{}
+	committer := newOffsetCommitter(commits, CommitStrategyAsync, nopLogger())
+	committer.Commit(t.Context(), testMessage(3))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		committer.Run(ctx, time.Millisecond)
+	}()
+
+	deadline := time.After(time.Second)
+	for len(commits.offsets()) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("pending offset was not flushed by the commit loop")
+		case <-time.After(time.Millisecond):
+		}
+	}
+	cancel()
+	<-done
+
+	if offsets := commits.offsets(); !slices.Equal(offsets, []int64{3}) {
+		t.Fatalf("committed %v, want [3]", offsets)
+	}
+}
+
+func TestAutoCommitLeavesCommitsToReader(t *testing.T) {
+	commits := &fakeCommitter{}
+	consumer := newTestConsumer(t, CommitStrategyAuto, commits, handlerFunc(func(context.Context, *domain.Message) error {
+		return nil
+	}))
+
+	queue := make(chan kafka.Message, 1)
+	queue <- testMessage(1)
+	close(queue)
+
+	consumer.runWorker(t.Context(), 0, queue)
+	consumer.committer.Commit(t.Context(), testMessage(1))
+	consumer.committer.Flush(t.Context())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		consumer.committer.Run(t.Context(), time.Millisecond)
+	}()
+	<-done
+
+	commits.mu.Lock()
+	calls := commits.calls
+	commits.mu.Unlock()
+	if calls != 0 {
+		t.Fatalf("CommitMessages called %d times with the auto strategy", calls)
+	}
+}
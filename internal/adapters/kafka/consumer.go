@@ -4,12 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/metrics"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/retry"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
 	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -18,26 +27,102 @@ var (
 	ErrConsumerNotStarted     = errors.New("consumer not started")
 )
 
+const (
+	OrderingByPartition = "partition"
+	OrderingByKey       = "key"
+
+	workerQueueSize = 64
+)
+
 type kafkaConsumer struct {
-	reader  *kafka.Reader
-	service ports.OrderService
-	log     logger.Logger
-	g       errgroup.Group
-	started bool
-	mu      sync.Mutex
-	cancel  context.CancelFunc
+	reader         *kafka.Reader
+	dlq            *deadLetterWriter
+	retry          *retry.Policy
+	routes         map[string]ports.MessageHandler
+	log            logger.Logger
+	offsets        *offsetTracker
+	committer      *offsetCommitter
+	ordering       string
+	readerStats    domain.ReaderStats
+	lastFetchError atomic.Value
+	lastFetch      atomic.Int64
+	fetchFailures  atomic.Int64
+	topics         []string
+	g              errgroup.Group
+	workers        int
+	batchSize      int
+	linger         time.Duration
+	interval       time.Duration
+	started        bool
+	mu             sync.Mutex
+	statsMu        sync.Mutex
+	cancelFetch    context.CancelFunc
+	cancelWork     context.CancelFunc
+	commitLoopDone chan struct{}
+}
+
+type BatchOptions struct {
+	Size   int
+	Linger time.Duration
 }
 
-func NewKafkaConsumer(reader *kafka.Reader, service ports.OrderService, log logger.Logger) ports.KafkaConsumer {
+type CommitOptions struct {
+	Strategy string
+	Interval time.Duration
+}
+
+func NewKafkaConsumer(reader *kafka.Reader, dlq *deadLetterWriter, policy *retry.Policy, routes map[string]ports.MessageHandler, workers int, ordering string, batch BatchOptions, commit CommitOptions, log logger.Logger) ports.KafkaConsumer {
+	if ordering != OrderingByKey {
+		ordering = OrderingByPartition
+	}
+
+	topics := make([]string, 0, len(routes))
+	for topic := range routes {
+		topics = append(topics, topic)
+	}
+	slices.Sort(topics)
+
 	return &kafkaConsumer{
-		reader:  reader,
-		service: service,
-		log:     log,
-		started: false,
+		reader:    reader,
+		dlq:       dlq,
+		retry:     policy,
+		routes:    routes,
+		log:       log,
+		offsets:   newOffsetTracker(),
+		committer: newOffsetCommitter(reader, commit.Strategy, log),
+		ordering:  ordering,
+		topics:    topics,
+		workers:   max(workers, 1),
+		batchSize: max(batch.Size, 1),
+		linger:    batch.Linger,
+		interval:  commit.Interval,
+		started:   false,
 	}
 }
 
-func NewKafkaConsumerWithConfig(cfg config.KafkaConfig, service ports.OrderService, log logger.Logger) ports.KafkaConsumer {
+func NewKafkaConsumerWithConfig(cfg config.KafkaConfig, retryCfg config.RetryConfig, handlers map[string]ports.MessageHandler, log logger.Logger) (ports.KafkaConsumer, error) {
+	strategy := ResolveCommitStrategy(cfg)
+	if strategy == CommitStrategyAsync && cfg.CommitInterval <= 0 {
+		return nil, fmt.Errorf("%w, got %s", ErrInvalidCommitInterval, cfg.CommitInterval)
+	}
+
+	topics := ResolveTopics(cfg)
+	routes, err := resolveRoutes(topics, handlers)
+	if err != nil {
+		return nil, fmt.Errorf("kafka topics: %w", err)
+	}
+	if len(topics) > 1 && cfg.GroupID == "" {
+		return nil, ErrGroupIDRequired
+	}
+
+	sec, err := newSecurity(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("kafka security: %w", err)
+	}
+	if sec.PlaintextCredentials() {
+		log.Warn("kafka SASL PLAIN is used without TLS, credentials are sent in plaintext")
+	}
+
 	offsetMap := map[string]int64{
 		"earliest": kafka.FirstOffset,
 		"latest":   kafka.LastOffset,
@@ -47,20 +132,40 @@ func NewKafkaConsumerWithConfig(cfg config.KafkaConfig, service ports.OrderServi
 		startOffset = kafka.LastOffset
 	}
 
+	var readerCommitInterval time.Duration
+	if strategy == CommitStrategyAuto {
+		readerCommitInterval = cfg.CommitInterval
+	}
+
 	readerConfig := kafka.ReaderConfig{
 		Brokers:        cfg.Brokers,
-		Topic:          cfg.Topic,
 		GroupID:        cfg.GroupID,
 		StartOffset:    startOffset,
 		MinBytes:       cfg.MinBytes,
 		MaxBytes:       cfg.MaxBytes,
 		MaxWait:        cfg.MaxWait,
 		SessionTimeout: cfg.SessionTimeout,
-		CommitInterval: cfg.CommitInterval,
+		CommitInterval: readerCommitInterval,
+		Dialer:         sec.Dialer(),
+	}
+
+	if len(topics) == 1 {
+		readerConfig.Topic = topics[0].Name
+	} else {
+		for _, topic := range topics {
+			readerConfig.GroupTopics = append(readerConfig.GroupTopics, topic.Name)
+		}
 	}
 
 	reader := kafka.NewReader(readerConfig)
-	return NewKafkaConsumer(reader, service, log)
+	dlq := newDeadLetterWriter(cfg.Brokers, cfg.DeadLetterTopic, sec.Transport(), retry.NewPolicy(retryCfg, nil), log)
+
+	policy := retry.NewPolicy(retryCfg, isRetryableProcessingError)
+
+	batch := BatchOptions{Size: cfg.BatchSize, Linger: cfg.BatchLinger}
+	commit := CommitOptions{Strategy: strategy, Interval: cfg.CommitInterval}
+
+	return NewKafkaConsumer(reader, dlq, policy, routes, cfg.Workers, cfg.OrderingKey, batch, commit, log), nil
 }
 
 func (c *kafkaConsumer) Start(ctx context.Context) error {
@@ -70,11 +175,17 @@ func (c *kafkaConsumer) Start(ctx context.Context) error {
 		return ErrConsumerAlreadyStarted
 	}
 	c.started = true
-	consumerCtx, cancel := context.WithCancel(ctx)
-	c.cancel = cancel
+	workCtx, cancelWork := context.WithCancel(ctx)
+	fetchCtx, cancelFetch := context.WithCancel(workCtx)
+	c.cancelWork = cancelWork
+	c.cancelFetch = cancelFetch
+	c.commitLoopDone = make(chan struct{})
 	c.mu.Unlock()
 
-	c.g.SetLimit(1)
+	queues := make([]chan kafka.Message, c.workers)
+	for i := range queues {
+		queues[i] = make(chan kafka.Message, workerQueueSize)
+	}
 
 	c.g.Go(func() error {
 		defer func() {
@@ -82,60 +193,375 @@ func (c *kafkaConsumer) Start(ctx context.Context) error {
 				c.log.Error("panic in consumer goroutine", "panic", fmt.Sprintf("%v", r))
 			}
 		}()
+		defer func() {
+			for _, queue := range queues {
+				close(queue)
+			}
+		}()
 
-		c.log.Info("starting kafka consumer", "topic", c.reader.Config().Topic, "group_id", c.reader.Config().GroupID)
+		c.log.Info("starting kafka consumer",
+			"topics", c.topics,
+			"group_id", c.reader.Config().GroupID,
+			"workers", c.workers,
+			"ordering", c.ordering,
+			"batch_size", c.batchSize,
+			"commit_strategy", c.committer.strategy)
 
 		for {
-			msg, err := c.reader.ReadMessage(consumerCtx)
+			msg, err := c.fetch(fetchCtx)
 			if err != nil {
 				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 					c.log.Info("consumer stopped due to context", "error", err)
 					return nil
 				}
-				c.log.Error("failed to read message from kafka", "error", err)
+				c.recordFetch(err)
+				c.log.Error("failed to fetch message from kafka", "error", err)
 				continue
 			}
 
 			c.log.Debug("received message from kafka", "topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset, "key", msg.Key)
+			c.recordFetch(nil)
+			metrics.MessagesConsumed.WithLabelValues(msg.Topic).Inc()
 
-			if err := c.service.ProcessMessage(consumerCtx, msg.Value); err != nil {
-				c.log.Error("failed to process message", "offset", msg.Offset, "error", err)
-				continue
+			if c.committer.strategy != CommitStrategyAuto {
+				c.offsets.Track(msg)
 			}
 
-			if err := c.reader.CommitMessages(consumerCtx, msg); err != nil {
-				c.log.Error("failed to commit message", "offset", msg.Offset, "error", err)
+			select {
+			case queues[c.workerIndex(msg)] <- msg:
+			case <-fetchCtx.Done():
+				c.log.Info("consumer stopped due to context", "error", fetchCtx.Err())
+				return nil
+			}
+		}
+	})
+
+	go func() {
+		defer close(c.commitLoopDone)
+		c.committer.Run(workCtx, c.interval)
+	}()
+
+	for workerID, queue := range queues {
+		c.g.Go(func() error {
+			if c.batchSize > 1 {
+				c.runBatchWorker(workCtx, workerID, queue)
 			} else {
-				c.log.Debug("message committed", "offset", msg.Offset)
+				c.runWorker(workCtx, workerID, queue)
 			}
+			return nil
+		})
+	}
+
+	return nil
+}
+
+func (c *kafkaConsumer) runWorker(ctx context.Context, workerID int, queue <-chan kafka.Message) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Error("panic in consumer worker", "worker", workerID, "panic", fmt.Sprintf("%v", r))
+		}
+	}()
+
+	for msg := range queue {
+		if c.offsets.Halted(msg) {
+			continue
+		}
+
+		err := c.consumeMessage(ctx, msg)
+
+		// Messages cut short by a forced stop stay uncommitted and are redelivered.
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			c.haltPartition(ctx, msg, err)
+			continue
+		}
+
+		if commitMsg, ready := c.offsets.Complete(msg); ready {
+			c.committer.Commit(ctx, commitMsg)
+		}
+	}
+}
+
+func (c *kafkaConsumer) consumeMessage(ctx context.Context, msg kafka.Message) error {
+	msgCtx, span := startMessageSpan(ctx, "process", msg)
+	defer span.End()
+
+	err := c.processWithRetry(msgCtx, msg)
+	recordOutcome(msg, err)
+	if err == nil {
+		return nil
+	}
+
+	tracing.RecordError(span, err)
+	return c.handleProcessingError(msgCtx, msg, err)
+}
+
+func (c *kafkaConsumer) haltPartition(ctx context.Context, msg kafka.Message, err error) {
+	c.offsets.Halt(msg)
+	c.log.WithContext(ctx).Error("message could not be dead-lettered, partition halted until it is redelivered",
+		"topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset, "error", err)
+}
+
+func (c *kafkaConsumer) runBatchWorker(ctx context.Context, workerID int, queue <-chan kafka.Message) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.log.Error("panic in consumer batch worker", "worker", workerID, "panic", fmt.Sprintf("%v", r))
+		}
+	}()
+
+	batch := make([]kafka.Message, 0, c.batchSize)
+	linger := time.NewTimer(c.linger)
+	linger.Stop()
+	defer linger.Stop()
+
+	for {
+		select {
+		case msg, ok := <-queue:
+			if !ok {
+				c.flushBatch(ctx, batch)
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if c.offsets.Halted(msg) {
+				continue
+			}
+			batch = append(batch, msg)
+			if len(batch) == 1 {
+				linger.Reset(c.linger)
+			}
+			if len(batch) >= c.batchSize {
+				linger.Stop()
+				c.flushBatch(ctx, batch)
+				batch = batch[:0]
+			}
+		case <-linger.C:
+			c.flushBatch(ctx, batch)
+			batch = batch[:0]
 		}
+	}
+}
+
+func (c *kafkaConsumer) flushBatch(ctx context.Context, batch []kafka.Message) {
+	if len(batch) == 0 {
+		return
+	}
+
+	msgCtxs := make([]context.Context, len(batch))
+	spans := make([]trace.Span, len(batch))
+	for i, msg := range batch {
+		msgCtxs[i], spans[i] = startMessageSpan(ctx, "process", msg)
+	}
+
+	results := c.handleBatch(ctx, batch, msgCtxs)
+
+	for i, msg := range batch {
+		err := results[i]
+		if err != nil && isRetryableProcessingError(err) {
+			err = c.processWithRetry(msgCtxs[i], msg)
+		}
+		recordOutcome(msg, err)
+		tracing.RecordError(spans[i], err)
+		if err != nil {
+			err = c.handleProcessingError(msgCtxs[i], msg, err)
+		}
+		spans[i].End()
+		if err != nil && ctx.Err() == nil {
+			c.haltPartition(ctx, msg, err)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	ready := make(map[topicPartition]kafka.Message, len(batch))
+	for _, msg := range batch {
+		if commitMsg, isReady := c.offsets.Complete(msg); isReady {
+			ready[partitionOf(commitMsg)] = commitMsg
+		}
+	}
+
+	commitMsgs := make([]kafka.Message, 0, len(ready))
+	for _, commitMsg := range ready {
+		commitMsgs = append(commitMsgs, commitMsg)
+	}
+	c.committer.Commit(ctx, commitMsgs...)
+
+	c.log.Debug("message batch flushed", "batch_size", len(batch), "partitions_committed", len(ready))
+}
+
+func (c *kafkaConsumer) workerIndex(msg kafka.Message) int {
+	if c.ordering == OrderingByKey && len(msg.Key) > 0 {
+		hasher := fnv.New32a()
+		_, _ = hasher.Write(msg.Key)
+		return int(hasher.Sum32() % uint32(c.workers))
+	}
+
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(msg.Topic))
+	return int((hasher.Sum32() + uint32(msg.Partition)) % uint32(c.workers))
+}
+
+func (c *kafkaConsumer) handle(ctx context.Context, msg kafka.Message) error {
+	handler, exists := c.routes[msg.Topic]
+	if !exists {
+		return domain.NewRejectedError(domain.ReasonNoHandler, fmt.Errorf("%w: %s", ErrNoHandler, msg.Topic))
+	}
+	return handler.HandleMessage(ctx, toDomainMessage(ctx, msg))
+}
+
+func (c *kafkaConsumer) handleBatch(ctx context.Context, batch []kafka.Message, msgCtxs []context.Context) []error {
+	results := make([]error, len(batch))
+
+	positionsByTopic := make(map[string][]int)
+	for i, msg := range batch {
+		positionsByTopic[msg.Topic] = append(positionsByTopic[msg.Topic], i)
+	}
+
+	for topic, positions := range positionsByTopic {
+		batchHandler, supportsBatch := c.routes[topic].(ports.BatchMessageHandler)
+		if !supportsBatch {
+			for _, position := range positions {
+				results[position] = c.handle(msgCtxs[position], batch[position])
+			}
+			continue
+		}
+
+		msgs := make([]*domain.Message, len(positions))
+		for i, position := range positions {
+			msgs[i] = toDomainMessage(msgCtxs[position], batch[position])
+		}
+		for i, err := range batchHandler.HandleBatch(ctx, msgs) {
+			results[positions[i]] = err
+		}
+	}
+
+	return results
+}
+
+func (c *kafkaConsumer) fetch(ctx context.Context) (kafka.Message, error) {
+	if c.committer.strategy == CommitStrategyAuto {
+		msg, err := c.reader.ReadMessage(ctx)
+		if err != nil {
+			return kafka.Message{}, fmt.Errorf("read message: %w", err)
+		}
+		return msg, nil
+	}
+
+	msg, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("fetch message: %w", err)
+	}
+	return msg, nil
+}
+
+func (c *kafkaConsumer) Stats() domain.CommitStats {
+	return c.committer.Stats()
+}
+
+func (c *kafkaConsumer) processWithRetry(ctx context.Context, msg kafka.Message) error {
+	err := c.retry.Do(ctx, func(ctx context.Context) error {
+		return c.handle(ctx, msg)
+	}, func(attempt int, delay time.Duration, err error) {
+		c.log.WithContext(ctx).Warn("message processing failed, retrying",
+			"topic", msg.Topic,
+			"partition", msg.Partition,
+			"offset", msg.Offset,
+			"attempt", attempt,
+			"max_attempts", c.retry.MaxAttempts(),
+			"backoff", delay,
+			"error", err)
 	})
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, retry.ErrAttemptsExhausted) {
+		return domain.NewFailedError(domain.ReasonRetriesExhausted, err)
+	}
+	return err
+}
+
+// Save failures were already retried by the service's save policy.
+func isRetryableProcessingError(err error) bool {
+	procErr, classified := domain.AsProcessingError(err)
+	return classified && !procErr.Rejected() && procErr.Reason != domain.ReasonSaveFailed
+}
+
+// handleProcessingError dead-letters the message; a non-nil error means it was
+// not, and its offset must not be committed.
+func (c *kafkaConsumer) handleProcessingError(ctx context.Context, msg kafka.Message, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("handle processing error: %w", ctx.Err())
+	}
+
+	procErr, classified := domain.AsProcessingError(err)
+	if !classified {
+		procErr = domain.NewFailedError(domain.ReasonUnexpectedError, err)
+	}
+
+	if c.dlq == nil && procErr.Rejected() {
+		c.log.WithContext(ctx).Warn("message rejected, dead-letter topic disabled, skipping",
+			"topic", msg.Topic, "offset", msg.Offset, "reason", procErr.Reason, "error", procErr.Err)
+		return nil
+	}
 
+	if dlqErr := c.dlq.Send(ctx, msg, procErr); dlqErr != nil {
+		return fmt.Errorf("dead-letter %s message: %w", procErr.Reason, dlqErr)
+	}
+	metrics.MessagesDeadLettered.WithLabelValues(msg.Topic, string(procErr.Reason)).Inc()
 	return nil
 }
 
-func (c *kafkaConsumer) Stop(_ context.Context) error {
+func (c *kafkaConsumer) Stop(ctx context.Context) error {
 	c.mu.Lock()
 	if !c.started {
 		c.mu.Unlock()
 		return ErrConsumerNotStarted
 	}
 	c.started = false
-	if c.cancel != nil {
-		c.cancel()
-	}
+	cancelFetch, cancelWork := c.cancelFetch, c.cancelWork
 	c.mu.Unlock()
 
 	c.log.Info("stopping kafka consumer")
 
+	cancelFetch()
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- c.g.Wait()
+	}()
+
+	var err error
+	select {
+	case err = <-drained:
+	case <-ctx.Done():
+		c.log.Warn("shutdown deadline reached, abandoning queued messages", "error", ctx.Err())
+		cancelWork()
+		err = <-drained
+	}
+	cancelWork()
+	<-c.commitLoopDone
+
+	if err != nil {
+		c.log.Error("consumer goroutine failed", "error", err)
+		return fmt.Errorf("consumer goroutine error: %w", err)
+	}
+
+	c.committer.Flush(ctx)
+
 	if err := c.reader.Close(); err != nil {
 		c.log.Error("failed to close kafka reader", "error", err)
 		return fmt.Errorf("close reader: %w", err)
 	}
 
-	if err := c.g.Wait(); err != nil {
-		c.log.Error("consumer goroutine failed", "error", err)
-		return fmt.Errorf("consumer goroutine error: %w", err)
+	if err := c.dlq.Close(); err != nil {
+		c.log.Error("failed to close dead-letter writer", "error", err)
+		return err
 	}
 
 	c.log.Info("kafka consumer stopped successfully")
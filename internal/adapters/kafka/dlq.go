@@ -0,0 +1,128 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/retry"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	HeaderDLQReason            = "dlq.reason"
+	HeaderDLQKind              = "dlq.kind"
+	HeaderDLQError             = "dlq.error"
+	HeaderDLQOriginalTopic     = "dlq.original.topic"
+	HeaderDLQOriginalPartition = "dlq.original.partition"
+	HeaderDLQOriginalOffset    = "dlq.original.offset"
+	HeaderDLQOriginalTimestamp = "dlq.original.timestamp"
+	HeaderDLQTimestamp         = "dlq.timestamp"
+)
+
+var ErrDeadLetterDisabled = errors.New("dead-letter topic is not configured")
+
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+type deadLetterWriter struct {
+	writer messageWriter
+	retry  *retry.Policy
+	log    logger.Logger
+	topic  string
+}
+
+func newDeadLetterWriter(brokers []string, topic string, transport *kafka.Transport, policy *retry.Policy, log logger.Logger) *deadLetterWriter {
+	if topic == "" {
+		return nil
+	}
+
+	return &deadLetterWriter{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+			Transport:              transport,
+		},
+		retry: policy,
+		log:   log,
+		topic: topic,
+	}
+}
+
+func (w *deadLetterWriter) Send(ctx context.Context, msg kafka.Message, procErr *domain.ProcessingError) error {
+	if w == nil {
+		return ErrDeadLetterDisabled
+	}
+
+	headers := make([]kafka.Header, 0, len(msg.Headers)+9)
+	headers = append(headers, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDLQReason, Value: []byte(procErr.Reason)},
+		kafka.Header{Key: HeaderDLQKind, Value: []byte(procErr.Kind)},
+		kafka.Header{Key: HeaderDLQError, Value: []byte(errorText(procErr.Err))},
+		kafka.Header{Key: HeaderDLQOriginalTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDLQOriginalPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDLQOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+		kafka.Header{Key: HeaderDLQOriginalTimestamp, Value: []byte(msg.Time.UTC().Format(time.RFC3339Nano))},
+		kafka.Header{Key: HeaderDLQTimestamp, Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+	)
+	if traceID, ok := logger.TraceIDFromContext(ctx); ok && !hasHeader(msg.Headers, domain.HeaderTraceID) {
+		headers = append(headers, kafka.Header{Key: domain.HeaderTraceID, Value: []byte(traceID)})
+	}
+
+	dlqMsg := kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+
+	err := w.retry.Do(ctx, func(ctx context.Context) error {
+		return w.writer.WriteMessages(ctx, dlqMsg)
+	}, func(attempt int, delay time.Duration, err error) {
+		w.log.WithContext(ctx).Warn("failed to write dead-letter message, retrying",
+			"topic", msg.Topic,
+			"partition", msg.Partition,
+			"offset", msg.Offset,
+			"attempt", attempt,
+			"backoff", delay,
+			"error", err)
+	})
+	if err != nil {
+		return fmt.Errorf("write dead-letter message: %w", err)
+	}
+
+	w.log.WithContext(ctx).Info("message sent to dead-letter topic",
+		"dlq_topic", w.topic,
+		"topic", msg.Topic,
+		"partition", msg.Partition,
+		"offset", msg.Offset,
+		"kind", procErr.Kind,
+		"reason", procErr.Reason)
+	return nil
+}
+
+func (w *deadLetterWriter) Close() error {
+	if w == nil {
+		return nil
+	}
+	if err := w.writer.Close(); err != nil {
+		return fmt.Errorf("close dead-letter writer: %w", err)
+	}
+	return nil
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,78 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const maxFetchFailures = 5
+
+var (
+	ErrBrokersUnreachable = errors.New("no kafka broker is reachable")
+	ErrFetchFailing       = errors.New("kafka fetches keep failing")
+)
+
+func (c *kafkaConsumer) recordFetch(err error) {
+	if err != nil {
+		c.fetchFailures.Add(1)
+		c.lastFetchError.Store(err.Error())
+		return
+	}
+	c.fetchFailures.Store(0)
+	c.lastFetch.Store(time.Now().UnixNano())
+}
+
+func (c *kafkaConsumer) HealthCheck(ctx context.Context) (map[string]any, error) {
+	c.mu.Lock()
+	started := c.started
+	c.mu.Unlock()
+
+	details := map[string]any{
+		"topics":                   c.topics,
+		"consecutive_fetch_errors": c.fetchFailures.Load(),
+		"halted_partitions":        c.offsets.HaltedPartitions(),
+	}
+	if lastFetch := c.lastFetch.Load(); lastFetch > 0 {
+		details["last_fetch"] = time.Unix(0, lastFetch).UTC().Format(time.RFC3339)
+	}
+	if lastErr, ok := c.lastFetchError.Load().(string); ok {
+		details["last_fetch_error"] = lastErr
+	}
+
+	if !started {
+		return details, ErrConsumerNotStarted
+	}
+
+	if err := c.dialAnyBroker(ctx); err != nil {
+		return details, err
+	}
+
+	if failures := c.fetchFailures.Load(); failures >= maxFetchFailures {
+		return details, fmt.Errorf("%w: %d in a row", ErrFetchFailing, failures)
+	}
+	return details, nil
+}
+
+func (c *kafkaConsumer) dialAnyBroker(ctx context.Context) error {
+	readerCfg := c.reader.Config()
+	dialer := readerCfg.Dialer
+	if dialer == nil {
+		dialer = kafka.DefaultDialer
+	}
+
+	var errs []error
+	for _, broker := range readerCfg.Brokers {
+		conn, err := dialer.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		_ = conn.Close()
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrBrokersUnreachable, errors.Join(errs...))
+}
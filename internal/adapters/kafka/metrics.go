@@ -0,0 +1,37 @@
+package kafka
+
+import (
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/metrics"
+	"github.com/segmentio/kafka-go"
+)
+
+const reasonUnclassified = "unclassified"
+
+func recordOutcome(msg kafka.Message, err error) {
+	reason := ""
+	if err != nil {
+		reason = reasonUnclassified
+		if procErr, classified := domain.AsProcessingError(err); classified {
+			reason = string(procErr.Reason)
+		}
+	}
+	metrics.MessagesProcessed.WithLabelValues(msg.Topic, metrics.Outcome(err), reason).Inc()
+}
+
+// ReaderStats accumulates kafka.Reader.Stats, which resets on every call.
+func (c *kafkaConsumer) ReaderStats() domain.ReaderStats {
+	stats := c.reader.Stats()
+
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	c.readerStats.Lag = stats.Lag
+	c.readerStats.Messages += stats.Messages
+	c.readerStats.Bytes += stats.Bytes
+	c.readerStats.Fetches += stats.Fetches
+	c.readerStats.Errors += stats.Errors
+	c.readerStats.Timeouts += stats.Timeouts
+	c.readerStats.Rebalances += stats.Rebalances
+	return c.readerStats
+}
@@ -0,0 +1,108 @@
+package kafka
+
+import (
+	"sync"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type topicPartition struct {
+	topic     string
+	partition int
+}
+
+func partitionOf(msg kafka.Message) topicPartition {
+	return topicPartition{topic: msg.Topic, partition: msg.Partition}
+}
+
+type partitionOffsets struct {
+	done    map[int64]kafka.Message
+	pending []int64
+	halted  bool
+}
+
+type offsetTracker struct {
+	partitions map[topicPartition]*partitionOffsets
+	mu         sync.Mutex
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{
+		partitions: make(map[topicPartition]*partitionOffsets),
+	}
+}
+
+func (t *offsetTracker) Track(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, exists := t.partitions[partitionOf(msg)]
+	if !exists || (len(state.pending) > 0 && msg.Offset <= state.pending[len(state.pending)-1]) {
+		// The partition was rewound (e.g. after a rebalance), earlier state is stale.
+		state = &partitionOffsets{done: make(map[int64]kafka.Message)}
+		t.partitions[partitionOf(msg)] = state
+	}
+
+	if !state.halted {
+		state.pending = append(state.pending, msg.Offset)
+	}
+}
+
+// Halt stops committing the partition at msg until it is redelivered.
+func (t *offsetTracker) Halt(msg kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if state, exists := t.partitions[partitionOf(msg)]; exists {
+		state.halted = true
+	}
+}
+
+func (t *offsetTracker) Halted(msg kafka.Message) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, exists := t.partitions[partitionOf(msg)]
+	return exists && state.halted
+}
+
+func (t *offsetTracker) HaltedPartitions() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var halted int
+	for _, state := range t.partitions {
+		if state.halted {
+			halted++
+		}
+	}
+	return halted
+}
+
+func (t *offsetTracker) Complete(msg kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	state, exists := t.partitions[partitionOf(msg)]
+	if !exists || state.halted || len(state.pending) == 0 || msg.Offset < state.pending[0] {
+		return kafka.Message{}, false
+	}
+
+	state.done[msg.Offset] = msg
+
+	var (
+		commit kafka.Message
+		ready  bool
+	)
+	for len(state.pending) > 0 {
+		head, isDone := state.done[state.pending[0]]
+		if !isDone {
+			break
+		}
+		delete(state.done, state.pending[0])
+		state.pending = state.pending[1:]
+		commit, ready = head, true
+	}
+
+	return commit, ready
+}
@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	HeaderEventType = "event.type"
+	HeaderEventID   = "event.id"
+)
+
+var ErrEventsTopicNotConfigured = errors.New("events topic is not configured")
+
+type eventPublisher struct {
+	writer *kafka.Writer
+	log    logger.Logger
+}
+
+func NewEventPublisher(cfg config.KafkaConfig, log logger.Logger) (ports.EventPublisher, error) {
+	if cfg.EventsTopic == "" {
+		return nil, ErrEventsTopicNotConfigured
+	}
+	if len(cfg.Brokers) == 0 {
+		return nil, ErrNoBrokers
+	}
+
+	sec, err := newSecurity(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("kafka security: %w", err)
+	}
+
+	return &eventPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(cfg.Brokers...),
+			Topic:                  cfg.EventsTopic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+			Transport:              sec.Transport(),
+		},
+		log: log,
+	}, nil
+}
+
+func (p *eventPublisher) Publish(ctx context.Context, events []domain.OutboxEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	msgs := make([]kafka.Message, len(events))
+	for i, event := range events {
+		msgs[i] = kafka.Message{
+			Key:   []byte(event.AggregateID),
+			Value: event.Payload,
+			Headers: []kafka.Header{
+				{Key: HeaderEventType, Value: []byte(event.EventType)},
+				{Key: HeaderEventID, Value: []byte(strconv.FormatInt(event.ID, 10))},
+			},
+			Time: event.CreatedAt,
+		}
+	}
+
+	if err := p.writer.WriteMessages(ctx, msgs...); err != nil {
+		return fmt.Errorf("write events: %w", err)
+	}
+
+	p.log.Debug("events published", "topic", p.writer.Topic, "events", len(events))
+	return nil
+}
+
+func (p *eventPublisher) Close() error {
+	if err := p.writer.Close(); err != nil {
+		return fmt.Errorf("close event writer: %w", err)
+	}
+	return nil
+}
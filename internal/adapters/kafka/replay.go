@@ -0,0 +1,341 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+	"github.com/segmentio/kafka-go"
+)
+
+var (
+	ErrInvalidReplayRequest = errors.New("replay requires a start timestamp or explicit offsets")
+	ErrMissingOffsets       = errors.New("partition has no explicit offsets and no start timestamp was given")
+	ErrUnknownPartition     = errors.New("partition does not exist")
+	ErrNoBrokers            = errors.New("no kafka brokers configured")
+	ErrReplayTopicRequired  = errors.New("replay topic is required when several topics are consumed")
+	ErrUnknownReplayTopic   = errors.New("replay topic is not a consumed topic")
+)
+
+const (
+	maxReplayOutcomes = 1000
+	replayIdleTimeout = 10 * time.Second
+)
+
+type replayer struct {
+	dialer   *kafka.Dialer
+	service  ports.OrderService
+	log      logger.Logger
+	topics   []string
+	brokers  []string
+	maxWait  time.Duration
+	minBytes int
+	maxBytes int
+}
+
+func NewReplayer(cfg config.KafkaConfig, service ports.OrderService, log logger.Logger) (ports.Replayer, error) {
+	sec, err := newSecurity(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("kafka security: %w", err)
+	}
+
+	var topics []string
+	for _, topic := range ResolveTopics(cfg) {
+		topics = append(topics, topic.Name)
+	}
+
+	return &replayer{
+		dialer:   sec.Dialer(),
+		service:  service,
+		log:      log,
+		topics:   topics,
+		brokers:  cfg.Brokers,
+		maxWait:  cfg.MaxWait,
+		minBytes: cfg.MinBytes,
+		maxBytes: cfg.MaxBytes,
+	}, nil
+}
+
+func (r *replayer) Replay(ctx context.Context, req domain.ReplayRequest) (*domain.ReplayReport, error) {
+	if err := validateReplayRequest(req); err != nil {
+		return nil, err
+	}
+	if len(r.brokers) == 0 {
+		return nil, ErrNoBrokers
+	}
+
+	topic, err := r.resolveTopic(req.Topic)
+	if err != nil {
+		return nil, err
+	}
+
+	partitions, err := r.selectPartitions(ctx, topic, req)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &domain.ReplayReport{
+		StartedAt: time.Now().UTC(),
+		Topic:     topic,
+		DryRun:    req.DryRun,
+	}
+
+	r.log.Info("starting replay",
+		"topic", topic,
+		"partitions", partitions,
+		"from", req.From,
+		"to", req.To,
+		"dry_run", req.DryRun)
+
+	for _, partition := range partitions {
+		if err := r.replayPartition(ctx, topic, partition, req, report); err != nil {
+			report.FinishedAt = time.Now().UTC()
+			r.log.Error("replay aborted", "partition", partition, "processed", report.Processed, "error", err)
+			return report, err
+		}
+	}
+
+	report.FinishedAt = time.Now().UTC()
+	r.log.Info("replay finished",
+		"topic", topic,
+		"processed", report.Processed,
+		"saved", report.Saved,
+		"rejected", report.Rejected,
+		"failed", report.Failed,
+		"dry_run", req.DryRun)
+
+	return report, nil
+}
+
+func validateReplayRequest(req domain.ReplayRequest) error {
+	if !req.From.IsZero() {
+		return nil
+	}
+	if len(req.Offsets) == 0 {
+		return ErrInvalidReplayRequest
+	}
+	for _, partition := range req.Partitions {
+		if _, ok := req.Offsets[partition]; !ok {
+			return fmt.Errorf("%w: %d", ErrMissingOffsets, partition)
+		}
+	}
+	return nil
+}
+
+func (r *replayer) resolveTopic(topic string) (string, error) {
+	if topic == "" {
+		if len(r.topics) != 1 {
+			return "", ErrReplayTopicRequired
+		}
+		return r.topics[0], nil
+	}
+	if !slices.Contains(r.topics, topic) {
+		return "", fmt.Errorf("%w: %s", ErrUnknownReplayTopic, topic)
+	}
+	return topic, nil
+}
+
+func (r *replayer) selectPartitions(ctx context.Context, topic string, req domain.ReplayRequest) ([]int, error) {
+	found, err := r.dialer.LookupPartitions(ctx, "tcp", r.brokers[0], topic)
+	if err != nil {
+		return nil, fmt.Errorf("lookup partitions: %w", err)
+	}
+
+	available := make([]int, 0, len(found))
+	for _, partition := range found {
+		available = append(available, partition.ID)
+	}
+	slices.Sort(available)
+
+	requested := slices.Clone(req.Partitions)
+	if len(requested) == 0 && len(req.Offsets) > 0 {
+		for partition := range req.Offsets {
+			requested = append(requested, partition)
+		}
+	}
+	if len(requested) == 0 {
+		return available, nil
+	}
+
+	slices.Sort(requested)
+	requested = slices.Compact(requested)
+	for _, partition := range requested {
+		if !slices.Contains(available, partition) {
+			return nil, fmt.Errorf("%w: %s/%d", ErrUnknownPartition, topic, partition)
+		}
+	}
+
+	return requested, nil
+}
+
+func (r *replayer) bounds(ctx context.Context, topic string, partition int, req domain.ReplayRequest) (int64, int64, error) {
+	conn, err := r.dialer.DialLeader(ctx, "tcp", r.brokers[0], topic, partition)
+	if err != nil {
+		return 0, 0, fmt.Errorf("dial partition leader: %w", err)
+	}
+	defer func() {
+		if closeErr := conn.Close(); closeErr != nil {
+			r.log.Warn("failed to close partition leader connection", "partition", partition, "error", closeErr)
+		}
+	}()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return 0, 0, fmt.Errorf("read partition offsets: %w", err)
+	}
+
+	if offsets, explicit := req.Offsets[partition]; explicit {
+		start, end := offsetBounds(offsets, first, last)
+		return start, end, nil
+	}
+
+	start, err := conn.ReadOffset(req.From)
+	if err != nil {
+		return 0, 0, fmt.Errorf("read offset at %s: %w", req.From.Format(time.RFC3339), err)
+	}
+	start, end := timestampBounds(start, first, last)
+	return start, end, nil
+}
+
+func offsetBounds(offsets domain.OffsetRange, first, last int64) (int64, int64) {
+	end := last
+	if offsets.End > 0 && offsets.End+1 < end {
+		end = offsets.End + 1
+	}
+	return max(offsets.Start, first), end
+}
+
+func timestampBounds(start, first, last int64) (int64, int64) {
+	// -1: no message was produced at or after the requested timestamp.
+	if start < 0 {
+		return last, last
+	}
+	return max(start, first), last
+}
+
+func (r *replayer) replayPartition(ctx context.Context, topic string, partition int, req domain.ReplayRequest, report *domain.ReplayReport) error {
+	start, end, err := r.bounds(ctx, topic, partition, req)
+	if err != nil {
+		return err
+	}
+
+	summary := domain.PartitionReplay{Partition: partition, StartOffset: start, EndOffset: end}
+	defer func() {
+		report.Partitions = append(report.Partitions, summary)
+	}()
+
+	if start >= end {
+		r.log.Info("nothing to replay in partition", "partition", partition, "start_offset", start, "end_offset", end)
+		return nil
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   r.brokers,
+		Topic:     topic,
+		Partition: partition,
+		Dialer:    r.dialer,
+		MinBytes:  r.minBytes,
+		MaxBytes:  r.maxBytes,
+		MaxWait:   r.maxWait,
+	})
+	defer func() {
+		if closeErr := reader.Close(); closeErr != nil {
+			r.log.Warn("failed to close replay reader", "partition", partition, "error", closeErr)
+		}
+	}()
+
+	if err := reader.SetOffset(start); err != nil {
+		return fmt.Errorf("seek partition %d to offset %d: %w", partition, start, err)
+	}
+
+	idleTimeout := max(replayIdleTimeout, 2*r.maxWait)
+	for {
+		msg, err := r.readMessage(ctx, reader, idleTimeout)
+		if errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			// The offsets left before end hold no data, e.g. transaction markers.
+			r.log.Info("no more messages to replay in partition", "partition", partition, "processed", summary.Processed, "end_offset", end)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("replay partition %d: %w", partition, err)
+		}
+
+		if !req.To.IsZero() && msg.Time.After(req.To) {
+			return nil
+		}
+
+		r.replayMessage(ctx, msg, req.DryRun, report)
+		summary.Processed++
+
+		if msg.Offset+1 >= end {
+			return nil
+		}
+	}
+}
+
+func (r *replayer) readMessage(ctx context.Context, reader *kafka.Reader, timeout time.Duration) (kafka.Message, error) {
+	readCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	msg, err := reader.ReadMessage(readCtx)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("read message: %w", err)
+	}
+	return msg, nil
+}
+
+func (r *replayer) replayMessage(ctx context.Context, msg kafka.Message, dryRun bool, report *domain.ReplayReport) {
+	outcome := domain.ReplayOutcome{Partition: msg.Partition, Offset: msg.Offset}
+
+	ctx, span := startMessageSpan(ctx, "replay", msg)
+	defer span.End()
+
+	message := toDomainMessage(ctx, msg)
+	order, err := r.service.ValidateMessage(ctx, message)
+	if err == nil {
+		outcome.Action = domain.ReplayWouldSave
+		if !dryRun {
+			outcome.Action = domain.ReplaySaved
+			err = r.service.SaveOrder(ctx, order)
+		}
+	}
+
+	if order != nil {
+		outcome.OrderUID = order.OrderUID
+	}
+
+	if err != nil {
+		tracing.RecordError(span, err)
+		outcome.Action = domain.ReplayFailed
+		outcome.Error = err.Error()
+		if procErr, classified := domain.AsProcessingError(err); classified {
+			outcome.Reason = procErr.Reason
+			if procErr.Rejected() {
+				outcome.Action = domain.ReplayRejected
+			}
+		}
+	}
+
+	report.Processed++
+	switch outcome.Action {
+	case domain.ReplaySaved, domain.ReplayWouldSave:
+		report.Saved++
+	case domain.ReplayRejected:
+		report.Rejected++
+	case domain.ReplayFailed:
+		report.Failed++
+	}
+
+	if len(report.Outcomes) < maxReplayOutcomes {
+		report.Outcomes = append(report.Outcomes, outcome)
+	} else {
+		report.Truncated = true
+	}
+}
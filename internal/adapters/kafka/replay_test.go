@@ -0,0 +1,166 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/segmentio/kafka-go"
+)
+
+var errSaveUnavailable = errors.New("database unavailable")
+
+type fakeOrderService struct {
+	ports.OrderService
+	results map[string]error
+	saved   []string
+}
+
+func (f *fakeOrderService) ValidateMessage(_ context.Context, msg *domain.Message) (*domain.Order, error) {
+	uid := string(msg.Value)
+	if uid == "" {
+		return nil, domain.NewRejectedError(domain.ReasonEmptyOrderUID, errors.New("empty order_uid"))
+	}
+	return &domain.Order{OrderUID: uid}, nil
+}
+
+func (f *fakeOrderService) SaveOrder(_ context.Context, order *domain.Order) error {
+	if err := f.results[order.OrderUID]; err != nil {
+		return err
+	}
+	f.saved = append(f.saved, order.OrderUID)
+	return nil
+}
+
+func replayTestMessages(t *testing.T, service *fakeOrderService, dryRun bool, values ...string) *domain.ReplayReport {
+	t.Helper()
+
+	r := &replayer{service: service, log: nopLogger()}
+	report := &domain.ReplayReport{DryRun: dryRun}
+	for i, value := range values {
+		msg := kafka.Message{Topic: testTopic, Partition: 0, Offset: int64(i), Value: []byte(value)}
+		r.replayMessage(t.Context(), msg, dryRun, report)
+	}
+	return report
+}
+
+func TestValidateReplayRequest(t *testing.T) {
+	tests := []struct {
+		want error
+		name string
+		req  domain.ReplayRequest
+	}{
+		{name: "empty", req: domain.ReplayRequest{}, want: ErrInvalidReplayRequest},
+		{name: "from", req: domain.ReplayRequest{From: time.Now(), Partitions: []int{0, 1}}},
+		{name: "offsets", req: domain.ReplayRequest{Offsets: map[int]domain.OffsetRange{0: {Start: 5}}}},
+		{
+			name: "offsets for every partition",
+			req:  domain.ReplayRequest{Offsets: map[int]domain.OffsetRange{0: {}, 1: {}}, Partitions: []int{0, 1}},
+		},
+		{
+			name: "partition without offsets",
+			req:  domain.ReplayRequest{Offsets: map[int]domain.OffsetRange{0: {}}, Partitions: []int{0, 1}},
+			want: ErrMissingOffsets,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateReplayRequest(tt.req)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("validateReplayRequest() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestOffsetBounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		offsets   domain.OffsetRange
+		wantStart int64
+		wantEnd   int64
+	}{
+		{name: "open range", offsets: domain.OffsetRange{Start: 20}, wantStart: 20, wantEnd: 100},
+		{name: "closed range", offsets: domain.OffsetRange{Start: 20, End: 29}, wantStart: 20, wantEnd: 30},
+		{name: "start before retention", offsets: domain.OffsetRange{Start: 0, End: 50}, wantStart: 10, wantEnd: 51},
+		{name: "end past last offset", offsets: domain.OffsetRange{Start: 20, End: 500}, wantStart: 20, wantEnd: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := offsetBounds(tt.offsets, 10, 100)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Fatalf("offsetBounds() = [%d, %d), want [%d, %d)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestTimestampBounds(t *testing.T) {
+	tests := []struct {
+		name      string
+		start     int64
+		wantStart int64
+		wantEnd   int64
+	}{
+		{name: "within partition", start: 42, wantStart: 42, wantEnd: 100},
+		{name: "before retention", start: 3, wantStart: 10, wantEnd: 100},
+		{name: "after last message", start: -1, wantStart: 100, wantEnd: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := timestampBounds(tt.start, 10, 100)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Fatalf("timestampBounds() = [%d, %d), want [%d, %d)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestReplayDryRunSavesNothing(t *testing.T) {
+	service := &fakeOrderService{}
+	report := replayTestMessages(t, service, true, "a", "", "b")
+
+	if len(service.saved) != 0 {
+		t.Fatalf("dry run saved orders %v", service.saved)
+	}
+	if report.Processed != 3 || report.Saved != 2 || report.Rejected != 1 || report.Failed != 0 {
+		t.Fatalf("report counts = processed %d, saved %d, rejected %d, failed %d; want 3, 2, 1, 0",
+			report.Processed, report.Saved, report.Rejected, report.Failed)
+	}
+
+	want := []domain.ReplayAction{domain.ReplayWouldSave, domain.ReplayRejected, domain.ReplayWouldSave}
+	for i, outcome := range report.Outcomes {
+		if outcome.Action != want[i] {
+			t.Fatalf("outcome %d action = %s, want %s", i, outcome.Action, want[i])
+		}
+	}
+	if report.Outcomes[1].Reason != domain.ReasonEmptyOrderUID {
+		t.Fatalf("rejected outcome reason = %s, want %s", report.Outcomes[1].Reason, domain.ReasonEmptyOrderUID)
+	}
+}
+
+func TestReplaySavesValidatedOrders(t *testing.T) {
+	service := &fakeOrderService{results: map[string]error{
+		"b": domain.NewFailedError(domain.ReasonSaveFailed, errSaveUnavailable),
+	}}
+	report := replayTestMessages(t, service, false, "a", "b", "")
+
+	if len(service.saved) != 1 || service.saved[0] != "a" {
+		t.Fatalf("saved orders = %v, want [a]", service.saved)
+	}
+	if report.Processed != 3 || report.Saved != 1 || report.Rejected != 1 || report.Failed != 1 {
+		t.Fatalf("report counts = processed %d, saved %d, rejected %d, failed %d; want 3, 1, 1, 1",
+			report.Processed, report.Saved, report.Rejected, report.Failed)
+	}
+
+	failed := report.Outcomes[1]
+	if failed.Action != domain.ReplayFailed || failed.OrderUID != "b" || failed.Reason != domain.ReasonSaveFailed {
+		t.Fatalf("failed outcome = %+v", failed)
+	}
+}
@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/segmentio/kafka-go"
+)
+
+const HandlerOrders = "orders"
+
+var (
+	ErrNoTopics        = errors.New("no kafka topics configured")
+	ErrDuplicateTopic  = errors.New("kafka topic configured more than once")
+	ErrUnknownHandler  = errors.New("unknown message handler")
+	ErrNoHandler       = errors.New("no handler registered for topic")
+	ErrGroupIDRequired = errors.New("group_id is required to consume several topics")
+)
+
+func ResolveTopics(cfg config.KafkaConfig) []config.KafkaTopicConfig {
+	if len(cfg.Topics) > 0 {
+		return cfg.Topics
+	}
+	if cfg.Topic == "" {
+		return nil
+	}
+	return []config.KafkaTopicConfig{{Name: cfg.Topic, Handler: HandlerOrders}}
+}
+
+func resolveRoutes(topics []config.KafkaTopicConfig, handlers map[string]ports.MessageHandler) (map[string]ports.MessageHandler, error) {
+	if len(topics) == 0 {
+		return nil, ErrNoTopics
+	}
+
+	routes := make(map[string]ports.MessageHandler, len(topics))
+	for _, topic := range topics {
+		if topic.Name == "" {
+			return nil, ErrNoTopics
+		}
+		if _, exists := routes[topic.Name]; exists {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateTopic, topic.Name)
+		}
+
+		handlerName := topic.Handler
+		if handlerName == "" {
+			handlerName = topic.Name
+		}
+		handler, exists := handlers[handlerName]
+		if !exists {
+			available := strings.Join(slices.Sorted(maps.Keys(handlers)), ", ")
+			return nil, fmt.Errorf("%w %q for topic %s (available: %s)", ErrUnknownHandler, handlerName, topic.Name, available)
+		}
+		routes[topic.Name] = handler
+	}
+
+	return routes, nil
+}
+
+func toDomainMessage(ctx context.Context, msg kafka.Message) *domain.Message {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+
+	traceID, _ := logger.TraceIDFromContext(ctx)
+
+	return &domain.Message{
+		Time:      msg.Time,
+		Headers:   headers,
+		Topic:     msg.Topic,
+		TraceID:   traceID,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Offset:    msg.Offset,
+		Partition: msg.Partition,
+	}
+}
@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/segmentio/kafka-go"
+)
+
+const statusTopic = "order-status-updates"
+
+func TestResolveTopics(t *testing.T) {
+	single := ResolveTopics(config.KafkaConfig{Topic: testTopic})
+	if len(single) != 1 || single[0].Name != testTopic || single[0].Handler != HandlerOrders {
+		t.Fatalf("ResolveTopics(topic) = %+v, want %s routed to %s", single, testTopic, HandlerOrders)
+	}
+
+	topics := []config.KafkaTopicConfig{{Name: statusTopic, Handler: HandlerOrders}}
+	if got := ResolveTopics(config.KafkaConfig{Topic: testTopic, Topics: topics}); !slices.Equal(got, topics) {
+		t.Fatalf("ResolveTopics(topics) = %+v, want %+v", got, topics)
+	}
+
+	if got := ResolveTopics(config.KafkaConfig{}); got != nil {
+		t.Fatalf("ResolveTopics(empty) = %+v, want nil", got)
+	}
+}
+
+func TestResolveRoutes(t *testing.T) {
+	handlers := map[string]ports.MessageHandler{HandlerOrders: handlerFunc(failFirstMessage)}
+
+	tests := []struct {
+		want   error
+		name   string
+		topics []config.KafkaTopicConfig
+	}{
+		{name: "handler by name", topics: []config.KafkaTopicConfig{{Name: statusTopic, Handler: HandlerOrders}}},
+		{name: "handler defaults to topic name", topics: []config.KafkaTopicConfig{{Name: testTopic}}},
+		{name: "no topics", want: ErrNoTopics},
+		{name: "empty topic name", topics: []config.KafkaTopicConfig{{Handler: HandlerOrders}}, want: ErrNoTopics},
+		{
+			name:   "duplicate topic",
+			topics: []config.KafkaTopicConfig{{Name: testTopic}, {Name: testTopic, Handler: HandlerOrders}},
+			want:   ErrDuplicateTopic,
+		},
+		{
+			name:   "unknown handler",
+			topics: []config.KafkaTopicConfig{{Name: testTopic}, {Name: statusTopic, Handler: "status"}},
+			want:   ErrUnknownHandler,
+		},
+		{name: "topic without matching handler", topics: []config.KafkaTopicConfig{{Name: statusTopic}}, want: ErrUnknownHandler},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, err := resolveRoutes(tt.topics, handlers)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("resolveRoutes() error = %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveRoutes() error = %v", err)
+			}
+			if len(routes) != len(tt.topics) {
+				t.Fatalf("resolveRoutes() = %d routes, want %d", len(routes), len(tt.topics))
+			}
+		})
+	}
+}
+
+func TestUnknownHandlerErrorListsAvailableHandlers(t *testing.T) {
+	_, err := NewKafkaConsumerWithConfig(config.KafkaConfig{
+		Brokers: []string{"localhost:9092"},
+		GroupID: "orders",
+		Topics:  []config.KafkaTopicConfig{{Name: testTopic}, {Name: statusTopic, Handler: "status"}},
+	}, config.RetryConfig{}, map[string]ports.MessageHandler{HandlerOrders: handlerFunc(failFirstMessage)}, nopLogger())
+
+	if !errors.Is(err, ErrUnknownHandler) {
+		t.Fatalf("NewKafkaConsumerWithConfig() error = %v, want %v", err, ErrUnknownHandler)
+	}
+	if !strings.Contains(err.Error(), `"status"`) || !strings.Contains(err.Error(), "available: "+HandlerOrders) {
+		t.Fatalf("error %q does not name the handler and the available handlers", err)
+	}
+}
+
+func TestMessagesRoutedByTopic(t *testing.T) {
+	handled := make(map[string][]int64)
+	record := func(name string) ports.MessageHandler {
+		return handlerFunc(func(_ context.Context, msg *domain.Message) error {
+			handled[name] = append(handled[name], msg.Offset)
+			return nil
+		})
+	}
+
+	commits := &fakeCommitter{}
+	consumer := newTestConsumer(t, CommitStrategySync, commits, record(testTopic))
+	consumer.routes[statusTopic] = record(statusTopic)
+
+	msgs := []kafka.Message{
+		{Topic: testTopic, Offset: 0},
+		{Topic: statusTopic, Offset: 0},
+		{Topic: testTopic, Offset: 1},
+		{Topic: "unrouted", Offset: 0},
+	}
+	queue := make(chan kafka.Message, len(msgs))
+	for _, msg := range msgs {
+		consumer.offsets.Track(msg)
+		queue <- msg
+	}
+	close(queue)
+
+	consumer.runWorker(t.Context(), 0, queue)
+
+	if got := handled[testTopic]; !slices.Equal(got, []int64{0, 1}) {
+		t.Fatalf("%s handler got offsets %v, want [0 1]", testTopic, got)
+	}
+	if got := handled[statusTopic]; !slices.Equal(got, []int64{0}) {
+		t.Fatalf("%s handler got offsets %v, want [0]", statusTopic, got)
+	}
+	if len(commits.committed) != len(msgs) {
+		t.Fatalf("committed %d messages, want %d with the unrouted one skipped", len(commits.committed), len(msgs))
+	}
+}
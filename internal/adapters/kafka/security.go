@@ -0,0 +1,150 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const (
+	SASLMechanismPlain       = "PLAIN"
+	SASLMechanismScramSHA256 = "SCRAM-SHA-256"
+	SASLMechanismScramSHA512 = "SCRAM-SHA-512"
+
+	dialTimeout = 10 * time.Second
+)
+
+var (
+	ErrTLSDisabled         = errors.New("kafka tls options are set but kafka.tls.enabled is false")
+	ErrTLSCertKeyMismatch  = errors.New("kafka.tls.cert_file and kafka.tls.key_file must be set together")
+	ErrTLSInvalidCA        = errors.New("kafka.tls.ca_file contains no valid PEM certificates")
+	ErrSASLUnsupported     = errors.New("unsupported kafka.sasl.mechanism, expected PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512")
+	ErrSASLMissingUsername = errors.New("kafka.sasl.username is required when sasl is enabled")
+	ErrSASLMissingPassword = errors.New("kafka.sasl.password is required when sasl is enabled")
+)
+
+type security struct {
+	tls  *tls.Config
+	sasl sasl.Mechanism
+}
+
+func ValidateSecurity(cfg config.KafkaConfig) error {
+	_, err := newSecurity(cfg)
+	return err
+}
+
+func newSecurity(cfg config.KafkaConfig) (*security, error) {
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	mechanism, err := newSASLMechanism(cfg.SASL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &security{tls: tlsConfig, sasl: mechanism}, nil
+}
+
+func (s *security) Dialer() *kafka.Dialer {
+	return &kafka.Dialer{
+		Timeout:       dialTimeout,
+		DualStack:     true,
+		TLS:           s.tls,
+		SASLMechanism: s.sasl,
+	}
+}
+
+func (s *security) Transport() *kafka.Transport {
+	return &kafka.Transport{
+		DialTimeout: dialTimeout,
+		TLS:         s.tls,
+		SASL:        s.sasl,
+	}
+}
+
+func (s *security) PlaintextCredentials() bool {
+	return s.tls == nil && s.sasl != nil && s.sasl.Name() == SASLMechanismPlain
+}
+
+func newTLSConfig(cfg config.KafkaTLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		if cfg.CAFile != "" || cfg.CertFile != "" || cfg.KeyFile != "" || cfg.InsecureSkipVerify {
+			return nil, ErrTLSDisabled
+		}
+		return nil, nil
+	}
+
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, ErrTLSCertKeyMismatch
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read kafka.tls.ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("%w: %s", ErrTLSInvalidCA, cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
+
+func newSASLMechanism(cfg config.KafkaSASLConfig) (sasl.Mechanism, error) {
+	mechanism := strings.ToUpper(strings.TrimSpace(cfg.Mechanism))
+	if mechanism == "" {
+		return nil, nil
+	}
+
+	if cfg.Username == "" {
+		return nil, ErrSASLMissingUsername
+	}
+	if cfg.Password == "" {
+		return nil, ErrSASLMissingPassword
+	}
+
+	switch mechanism {
+	case SASLMechanismPlain:
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case SASLMechanismScramSHA256, SASLMechanismScramSHA512:
+		algorithm := scram.SHA256
+		if mechanism == SASLMechanismScramSHA512 {
+			algorithm = scram.SHA512
+		}
+		scramMechanism, err := scram.Mechanism(algorithm, cfg.Username, cfg.Password)
+		if err != nil {
+			return nil, fmt.Errorf("create %s mechanism: %w", mechanism, err)
+		}
+		return scramMechanism, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrSASLUnsupported, cfg.Mechanism)
+	}
+}
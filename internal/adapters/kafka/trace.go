@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = tracing.Tracer("adapters/kafka")
+
+type headerCarrier struct {
+	headers *[]kafka.Header
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, header := range *c.headers {
+		if strings.EqualFold(header.Key, key) {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c headerCarrier) Set(key, value string) {
+	for i, header := range *c.headers {
+		if strings.EqualFold(header.Key, key) {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, 0, len(*c.headers))
+	for _, header := range *c.headers {
+		keys = append(keys, header.Key)
+	}
+	return keys
+}
+
+func startMessageSpan(ctx context.Context, operation string, msg kafka.Message) (context.Context, trace.Span) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headerCarrier{headers: &msg.Headers})
+	ctx, span := tracer.Start(ctx, msg.Topic+" "+operation,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation.name", operation),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.String("messaging.destination.partition.id", strconv.Itoa(msg.Partition)),
+			attribute.Int64("messaging.kafka.offset", msg.Offset),
+			attribute.String("messaging.kafka.message.key", string(msg.Key)),
+		))
+
+	return logger.WithTraceID(ctx, messageTraceID(ctx, msg)), span
+}
+
+func messageTraceID(ctx context.Context, msg kafka.Message) string {
+	var requestID, traceParent string
+	for _, header := range msg.Headers {
+		value := strings.TrimSpace(string(header.Value))
+		switch strings.ToLower(header.Key) {
+		case domain.HeaderTraceID:
+			if logger.IsValidTraceID(value) {
+				return value
+			}
+		case domain.HeaderRequestID:
+			requestID = value
+		case domain.HeaderTraceParent:
+			traceParent = value
+		}
+	}
+
+	if logger.IsValidTraceID(requestID) {
+		return requestID
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		return spanContext.TraceID().String()
+	}
+	if traceID, ok := logger.TraceIDFromTraceParent(traceParent); ok {
+		return traceID
+	}
+	return logger.NewTraceID()
+}
+
+func hasHeader(headers []kafka.Header, key string) bool {
+	for _, header := range headers {
+		if strings.EqualFold(header.Key, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/server"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/health"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+)
+
+func LivenessHandler(registry *health.Registry) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		return writeReport(ctx, registry.Liveness(server.RequestContext(ctx)))
+	}
+}
+
+func ReadinessHandler(registry *health.Registry, log logger.Logger) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		report := registry.Readiness(server.RequestContext(ctx))
+		if !report.OK() {
+			log.WithContext(ctx).Warn("readiness check failed", "checks", report.Checks)
+		}
+		return writeReport(ctx, report)
+	}
+}
+
+func writeReport(ctx fiber.Ctx, report health.Report) error {
+	status := fiber.StatusOK
+	if !report.OK() {
+		status = fiber.StatusServiceUnavailable
+	}
+	return ctx.Status(status).JSON(report)
+}
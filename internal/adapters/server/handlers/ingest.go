@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"slices"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/server"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/gofiber/fiber/v3"
+)
+
+const (
+	headerIdempotencyKey      = "Idempotency-Key"
+	headerIdempotentReplayed  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	defaultIngestMaxBatchSize = 1000
+
+	ingestSaved    = "saved"
+	ingestRejected = "rejected"
+	ingestFailed   = "failed"
+)
+
+var errInvalidBatch = errors.New("invalid order batch")
+
+type ingestError struct {
+	Reason  domain.FailureReason `json:"reason,omitempty"`
+	Field   string               `json:"field,omitempty"`
+	Message string               `json:"message"`
+}
+
+type ingestResult struct {
+	Error    *ingestError `json:"error,omitempty"`
+	OrderUID string       `json:"order_uid,omitempty"`
+	Status   string       `json:"status"`
+	Index    int          `json:"index"`
+}
+
+type ingestBatchResponse struct {
+	Results  []ingestResult `json:"results"`
+	Saved    int            `json:"saved"`
+	Rejected int            `json:"rejected"`
+	Failed   int            `json:"failed"`
+}
+
+// OrderIngest accepts orders over HTTP for producers that cannot use Kafka.
+type OrderIngest struct {
+	service      ports.OrderService
+	guard        ports.IdempotencyGuard
+	log          logger.Logger
+	maxBatchSize int
+}
+
+func NewOrderIngest(service ports.OrderService, guard ports.IdempotencyGuard, maxBatchSize int, log logger.Logger) *OrderIngest {
+	if maxBatchSize <= 0 {
+		maxBatchSize = defaultIngestMaxBatchSize
+	}
+	return &OrderIngest{
+		service:      service,
+		guard:        guard,
+		log:          log,
+		maxBatchSize: maxBatchSize,
+	}
+}
+
+func (i *OrderIngest) SingleHandler() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		msg := newMessage(slices.Clone(ctx.Body()), ctx.Get(fiber.HeaderContentType))
+
+		return i.serve(ctx, func(requestCtx context.Context) domain.IdempotentResponse {
+			result := newIngestResult(0, msg.Value, i.service.HandleMessage(requestCtx, msg))
+			i.log.WithContext(requestCtx).Info("order ingested over HTTP", "order_uid", result.OrderUID, "status", result.Status)
+
+			switch result.Status {
+			case ingestSaved:
+				return jsonResponse(fiber.StatusOK, result)
+			case ingestRejected:
+				return jsonResponse(fiber.StatusUnprocessableEntity, result)
+			default:
+				return jsonResponse(fiber.StatusServiceUnavailable, result)
+			}
+		})
+	}
+}
+
+// BatchHandler answers 503 when any order failed to save.
+func (i *OrderIngest) BatchHandler() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		payloads, err := splitBatch(ctx.Get(fiber.HeaderContentType), ctx.Body())
+		if err != nil {
+			i.log.WithContext(ctx).Warn("invalid order batch", "error", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if len(payloads) > i.maxBatchSize {
+			return ctx.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"error": fmt.Sprintf("batch of %d orders exceeds the limit of %d", len(payloads), i.maxBatchSize),
+			})
+		}
+
+		msgs := make([]*domain.Message, len(payloads))
+		for index, payload := range payloads {
+			msgs[index] = newMessage(payload, fiber.MIMEApplicationJSON)
+		}
+
+		return i.serve(ctx, func(requestCtx context.Context) domain.IdempotentResponse {
+			response := ingestBatchResponse{Results: make([]ingestResult, len(msgs))}
+			for index, err := range i.service.HandleBatch(requestCtx, msgs) {
+				result := newIngestResult(index, msgs[index].Value, err)
+				switch result.Status {
+				case ingestSaved:
+					response.Saved++
+				case ingestRejected:
+					response.Rejected++
+				default:
+					response.Failed++
+				}
+				response.Results[index] = result
+			}
+
+			i.log.WithContext(requestCtx).Info("order batch ingested over HTTP",
+				"batch_size", len(msgs), "saved", response.Saved, "rejected", response.Rejected, "failed", response.Failed)
+			if response.Failed > 0 {
+				return jsonResponse(fiber.StatusServiceUnavailable, response)
+			}
+			return jsonResponse(fiber.StatusOK, response)
+		})
+	}
+}
+
+func newMessage(payload []byte, contentType string) *domain.Message {
+	return &domain.Message{Value: payload, Headers: map[string]string{domain.HeaderContentType: contentType}}
+}
+
+// serve runs the request once per Idempotency-Key when the header is set.
+func (i *OrderIngest) serve(ctx fiber.Ctx, run func(context.Context) domain.IdempotentResponse) error {
+	requestCtx := server.RequestContext(ctx)
+	key := ctx.Get(headerIdempotencyKey)
+	if key == "" {
+		return writeResponse(ctx, run(requestCtx))
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("%s must be at most %d characters", headerIdempotencyKey, maxIdempotencyKeyLength)})
+	}
+
+	response, replayed, err := i.guard.Do(requestCtx, key, requestHash(ctx), run)
+	switch {
+	case errors.Is(err, domain.ErrIdempotencyKeyReused):
+		i.log.WithContext(ctx).Warn("idempotency key reused with a different request", "idempotency_key", key)
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, domain.ErrRequestInProgress):
+		return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		i.log.WithContext(ctx).Error("failed to check idempotency key", "idempotency_key", key, "error", err)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to check idempotency key"})
+	}
+
+	if replayed {
+		ctx.Set(headerIdempotentReplayed, "true")
+	}
+	return writeResponse(ctx, response)
+}
+
+func writeResponse(ctx fiber.Ctx, response domain.IdempotentResponse) error {
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return ctx.Status(response.StatusCode).Send(response.Body)
+}
+
+func jsonResponse(status int, body any) domain.IdempotentResponse {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return domain.IdempotentResponse{StatusCode: fiber.StatusInternalServerError, Body: []byte(`{"error":"Failed to encode response"}`)}
+	}
+	return domain.IdempotentResponse{StatusCode: status, Body: payload}
+}
+
+// requestHash tells a retry from a different request sent with the same key.
+func requestHash(ctx fiber.Ctx) string {
+	hash := sha256.New()
+	for _, part := range []string{ctx.Method(), ctx.Path(), ctx.Get(fiber.HeaderContentType)} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(ctx.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// splitBatch cuts an NDJSON stream or a JSON array into one payload per order.
+func splitBatch(contentType string, body []byte) ([][]byte, error) {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		var payloads [][]byte
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		scanner.Buffer(make([]byte, 0, 64<<10), len(body)+1)
+		for scanner.Scan() {
+			if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+				payloads = append(payloads, slices.Clone(line))
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %w", errInvalidBatch, err)
+		}
+		if len(payloads) == 0 {
+			return nil, fmt.Errorf("%w: no orders", errInvalidBatch)
+		}
+		return payloads, nil
+	case "", fiber.MIMEApplicationJSON:
+		var elements []json.RawMessage
+		if err := json.Unmarshal(body, &elements); err != nil {
+			return nil, fmt.Errorf("%w: expected a JSON array of orders", errInvalidBatch)
+		}
+		if len(elements) == 0 {
+			return nil, fmt.Errorf("%w: no orders", errInvalidBatch)
+		}
+		payloads := make([][]byte, len(elements))
+		for index, element := range elements {
+			payloads[index] = element
+		}
+		return payloads, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported content type %q, use application/json or application/x-ndjson", errInvalidBatch, contentType)
+	}
+}
+
+func newIngestResult(index int, payload []byte, err error) ingestResult {
+	result := ingestResult{Index: index, OrderUID: peekOrderUID(payload), Status: ingestSaved}
+	if err == nil {
+		return result
+	}
+
+	result.Status = ingestFailed
+	result.Error = &ingestError{Message: err.Error()}
+	if procErr, ok := domain.AsProcessingError(err); ok {
+		result.Error.Reason = procErr.Reason
+		result.Error.Message = procErr.Err.Error()
+		if procErr.Rejected() {
+			result.Status = ingestRejected
+		}
+	}
+	var fieldErr *domain.FieldError
+	if errors.As(err, &fieldErr) {
+		result.Error.Field = fieldErr.Field
+		result.Error.Message = fieldErr.Err.Error()
+	}
+	return result
+}
+
+func peekOrderUID(payload []byte) string {
+	var order struct {
+		OrderUID string `json:"order_uid"`
+	}
+	_ = json.Unmarshal(payload, &order)
+	return order.OrderUID
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSplitBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        []string
+		wantErr     bool
+	}{
+		{
+			name:        "json array",
+			contentType: "application/json; charset=utf-8",
+			body:        `[{"order_uid":"a"}, {"order_uid":"b"}]`,
+			want:        []string{`{"order_uid":"a"}`, `{"order_uid":"b"}`},
+		},
+		{
+			name: "json array without content type",
+			body: `[{"order_uid":"a"}]`,
+			want: []string{`{"order_uid":"a"}`},
+		},
+		{
+			name:        "ndjson skips blank lines",
+			contentType: "application/x-ndjson",
+			body:        "{\"order_uid\":\"a\"}\n\n  {\"order_uid\":\"b\"}  \r\n",
+			want:        []string{`{"order_uid":"a"}`, `{"order_uid":"b"}`},
+		},
+		{
+			name:        "ndjson keeps invalid lines for per-order results",
+			contentType: "application/ndjson",
+			body:        "{\"order_uid\":\"a\"}\nnot json\n",
+			want:        []string{`{"order_uid":"a"}`, `not json`},
+		},
+		{name: "empty json array", contentType: "application/json", body: `[]`, wantErr: true},
+		{name: "json object", contentType: "application/json", body: `{"order_uid":"a"}`, wantErr: true},
+		{name: "empty ndjson", contentType: "application/jsonl", body: "\n \n", wantErr: true},
+		{name: "unsupported content type", contentType: "text/csv", body: "a,b", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payloads, err := splitBatch(tt.contentType, []byte(tt.body))
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidBatch) {
+					t.Fatalf("splitBatch() error = %v, want errInvalidBatch", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("splitBatch() error = %v", err)
+			}
+			if len(payloads) != len(tt.want) {
+				t.Fatalf("splitBatch() returned %d payloads, want %d", len(payloads), len(tt.want))
+			}
+			for index, payload := range payloads {
+				if string(payload) != tt.want[index] {
+					t.Fatalf("payload %d = %s, want %s", index, payload, tt.want[index])
+				}
+			}
+		})
+	}
+}
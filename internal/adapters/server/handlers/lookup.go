@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/server"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/gofiber/fiber/v3"
+	"golang.org/x/sync/singleflight"
+)
+
+// maxLookupResults caps lookups by non-unique keys.
+const maxLookupResults = 100
+
+type lookupResponse struct {
+	Orders    []*domain.Order `json:"orders"`
+	Truncated bool            `json:"truncated"`
+}
+
+// OrderLookupHandler serves orders by a secondary key, cache index first.
+func OrderLookupHandler(index domain.OrderIndex, cache ports.Cache, repo ports.OrderRepository, log logger.Logger) fiber.Handler {
+	var group singleflight.Group
+
+	return func(ctx fiber.Ctx) error {
+		requestCtx := server.RequestContext(ctx)
+		key := ctx.Params("key")
+		if err := index.ValidateKey(key); err != nil {
+			log.WithContext(ctx).Warn("invalid lookup key", "index", index, "key", key, "error", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		orders, complete := cache.Find(requestCtx, index, key)
+		source := "cache"
+		if !complete {
+			source = "db"
+			result, err, _ := group.Do(key, func() (any, error) {
+				loadCtx, cancel := context.WithTimeout(context.WithoutCancel(requestCtx), lookupTimeout)
+				defer cancel()
+				return loadIndexed(loadCtx, index, key, cache, repo)
+			})
+			if err != nil {
+				log.WithContext(ctx).Error("failed to look up orders in DB", "index", index, "key", key, "error", err)
+				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load orders"})
+			}
+			orders, _ = result.([]*domain.Order)
+		}
+
+		slices.SortFunc(orders, func(a, b *domain.Order) int {
+			return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(b.OrderUID, a.OrderUID))
+		})
+		log.WithContext(ctx).Debug("orders looked up", "index", index, "key", key, "orders", len(orders), "source", source)
+
+		if index.Unique() {
+			if len(orders) == 0 {
+				return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Order not found"})
+			}
+			return ctx.JSON(orders[0])
+		}
+
+		response := lookupResponse{Orders: orders}
+		if len(orders) > maxLookupResults {
+			response.Orders, response.Truncated = orders[:maxLookupResults], true
+		}
+		return ctx.JSON(response)
+	}
+}
+
+// loadIndexed reads one extra order to tell a complete result from a truncated one.
+func loadIndexed(ctx context.Context, index domain.OrderIndex, key string, cache ports.Cache, repo ports.OrderRepository) ([]*domain.Order, error) {
+	orders, err := repo.FindOrders(ctx, index, key, maxLookupResults+1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case len(orders) == 0:
+	case len(orders) > maxLookupResults:
+		for _, order := range orders {
+			cache.Set(ctx, order)
+		}
+	default:
+		cache.SetComplete(ctx, index, key, orders)
+	}
+	return orders, nil
+}
@@ -1,34 +1,145 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/server"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
 	"github.com/gofiber/fiber/v3"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
 )
 
-func OrderHandler(cache ports.Cache, repo ports.OrderRepository, log logger.Logger) fiber.Handler {
+const (
+	lookupTimeout       = 5 * time.Second
+	maxNegativeEntries  = 10000
+	negativeSweepPeriod = time.Minute
+)
+
+func OrderHandler(cache ports.Cache, repo ports.OrderRepository, negativeTTL time.Duration, log logger.Logger) fiber.Handler {
+	lookup := newOrderLookup(cache, repo, negativeTTL)
+
 	return func(ctx fiber.Ctx) error {
+		requestCtx := server.RequestContext(ctx)
 		orderUID := ctx.Params("id")
 		if orderUID == "" {
 			log.WithContext(ctx).Warn("empty order UID in request")
 			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Order UID is required"})
 		}
 
-		if order, found := cache.Get(orderUID); found {
+		if order, found := cache.Get(requestCtx, orderUID); found {
 			log.WithContext(ctx).Debug("order found in cache", "order_uid", orderUID)
 			return ctx.JSON(order)
 		}
 
-		order, err := repo.GetOrder(ctx, orderUID)
-		if err != nil {
-			log.WithContext(ctx).Error("failed to get order from DB", "order_uid", orderUID, "error", err)
+		order, shared, err := lookup.load(requestCtx, orderUID)
+		switch {
+		case errors.Is(err, domain.ErrOrderNotFound):
+			log.WithContext(ctx).Debug("order not found", "order_uid", orderUID, "error", err)
 			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Order not found"})
+		case err != nil:
+			log.WithContext(ctx).Error("failed to get order from DB", "order_uid", orderUID, "error", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to load order"})
 		}
 
-		cache.Set(order)
-		log.WithContext(ctx).Info("order retrieved from DB and cached", "order_uid", orderUID)
-
+		log.WithContext(ctx).Info("order retrieved from DB and cached", "order_uid", orderUID, "shared", shared)
 		return ctx.JSON(order)
 	}
 }
+
+// orderLookup coalesces concurrent cache misses and remembers unknown UIDs for negativeTTL.
+type orderLookup struct {
+	cache       ports.Cache
+	repo        ports.OrderRepository
+	missing     map[string]time.Time
+	group       singleflight.Group
+	lastSweep   time.Time
+	negativeTTL time.Duration
+	mu          sync.Mutex
+}
+
+func newOrderLookup(cache ports.Cache, repo ports.OrderRepository, negativeTTL time.Duration) *orderLookup {
+	return &orderLookup{
+		cache:       cache,
+		repo:        repo,
+		missing:     make(map[string]time.Time),
+		negativeTTL: negativeTTL,
+	}
+}
+
+func (l *orderLookup) load(ctx context.Context, orderUID string) (*domain.Order, bool, error) {
+	if l.knownMissing(orderUID) {
+		return nil, false, domain.ErrOrderNotFound
+	}
+
+	result, err, shared := l.group.Do(orderUID, func() (any, error) {
+		// Shared by every waiting request, so detached from the caller's cancellation.
+		loadCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), lookupTimeout)
+		defer cancel()
+
+		order, err := l.repo.GetOrder(loadCtx, orderUID)
+		if err != nil {
+			if errors.Is(err, domain.ErrOrderNotFound) {
+				l.rememberMissing(orderUID)
+			}
+			return nil, err
+		}
+
+		l.cache.Set(loadCtx, order)
+		return order, nil
+	})
+	if err != nil {
+		return nil, shared, err
+	}
+
+	order, _ := result.(*domain.Order)
+	return order, shared, nil
+}
+
+func (l *orderLookup) knownMissing(orderUID string) bool {
+	if l.negativeTTL <= 0 {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt, found := l.missing[orderUID]
+	if !found {
+		return false
+	}
+	if time.Now().After(expiresAt) {
+		delete(l.missing, orderUID)
+		return false
+	}
+	return true
+}
+
+func (l *orderLookup) rememberMissing(orderUID string) {
+	if l.negativeTTL <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if len(l.missing) >= maxNegativeEntries || now.Sub(l.lastSweep) > negativeSweepPeriod {
+		for uid, expiresAt := range l.missing {
+			if now.After(expiresAt) {
+				delete(l.missing, uid)
+			}
+		}
+		l.lastSweep = now
+	}
+	if len(l.missing) >= maxNegativeEntries {
+		clear(l.missing)
+	}
+
+	l.missing[orderUID] = now.Add(l.negativeTTL)
+}
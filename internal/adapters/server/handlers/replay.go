@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/gofiber/fiber/v3"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+)
+
+const (
+	replayStatusRunning  = "running"
+	replayStatusFinished = "finished"
+	replayStatusFailed   = "failed"
+
+	maxReplayJobs = 20
+)
+
+type replayJob struct {
+	StartedAt  time.Time            `json:"started_at"`
+	FinishedAt *time.Time           `json:"finished_at,omitempty"`
+	Report     *domain.ReplayReport `json:"report,omitempty"`
+	ID         string               `json:"id"`
+	Status     string               `json:"status"`
+	Error      string               `json:"error,omitempty"`
+}
+
+type ReplayJobs struct {
+	ctx      context.Context
+	replayer ports.Replayer
+	log      logger.Logger
+	jobs     map[string]*replayJob
+	order    []string
+	running  string
+	mu       sync.Mutex
+}
+
+func NewReplayJobs(ctx context.Context, replayer ports.Replayer, log logger.Logger) *ReplayJobs {
+	return &ReplayJobs{
+		ctx:      ctx,
+		replayer: replayer,
+		log:      log,
+		jobs:     make(map[string]*replayJob),
+	}
+}
+
+func (j *ReplayJobs) StartHandler() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		var req domain.ReplayRequest
+		if err := json.Unmarshal(ctx.Body(), &req); err != nil {
+			j.log.WithContext(ctx).Warn("invalid replay request body", "error", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid replay request"})
+		}
+
+		if req.From.IsZero() && len(req.Offsets) == 0 {
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Either from or offsets is required"})
+		}
+		if req.From.IsZero() {
+			for _, partition := range req.Partitions {
+				if _, ok := req.Offsets[partition]; !ok {
+					return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Offsets are required for every listed partition when from is not set", "partition": partition})
+				}
+			}
+		}
+
+		job, started := j.start(req)
+		if !started {
+			return ctx.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Replay already running", "id": job.ID})
+		}
+
+		j.log.WithContext(ctx).Info("replay job started", "job_id", job.ID, "dry_run", req.DryRun)
+		return ctx.Status(fiber.StatusAccepted).JSON(job)
+	}
+}
+
+func (j *ReplayJobs) StatusHandler() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		job, found := j.get(ctx.Params("id"))
+		if !found {
+			return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Replay job not found"})
+		}
+		return ctx.JSON(job)
+	}
+}
+
+func (j *ReplayJobs) start(req domain.ReplayRequest) (replayJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.running != "" {
+		return *j.jobs[j.running], false
+	}
+
+	job := &replayJob{
+		StartedAt: time.Now().UTC(),
+		ID:        newJobID(),
+		Status:    replayStatusRunning,
+	}
+	j.jobs[job.ID] = job
+	j.order = append(j.order, job.ID)
+	j.running = job.ID
+
+	if len(j.order) > maxReplayJobs {
+		delete(j.jobs, j.order[0])
+		j.order = j.order[1:]
+	}
+
+	go j.run(job.ID, req)
+
+	return *job, true
+}
+
+func (j *ReplayJobs) run(jobID string, req domain.ReplayRequest) {
+	report, err := j.replayer.Replay(j.ctx, req)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	finishedAt := time.Now().UTC()
+	job := j.jobs[jobID]
+	job.FinishedAt = &finishedAt
+	job.Report = report
+	job.Status = replayStatusFinished
+	if err != nil {
+		job.Status = replayStatusFailed
+		job.Error = err.Error()
+		j.log.Error("replay job failed", "job_id", jobID, "error", err)
+	} else {
+		j.log.Info("replay job finished", "job_id", jobID, "processed", report.Processed)
+	}
+	j.running = ""
+}
+
+func (j *ReplayJobs) get(jobID string) (replayJob, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job, found := j.jobs[jobID]
+	if !found {
+		return replayJob{}, false
+	}
+	return *job, true
+}
+
+func newJobID() string {
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/server"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/gofiber/fiber/v3"
+)
+
+type searchResponse struct {
+	Orders     []*domain.Order `json:"orders"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// OrderSearchHandler lists orders one keyset page at a time, bypassing the cache.
+func OrderSearchHandler(repo ports.OrderRepository, log logger.Logger) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		search, err := parseOrderSearch(ctx)
+		if err != nil {
+			log.WithContext(ctx).Warn("invalid order search", "query", string(ctx.Request().URI().QueryString()), "error", err)
+			return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+
+		page, err := repo.SearchOrders(server.RequestContext(ctx), search)
+		if err != nil {
+			log.WithContext(ctx).Error("failed to search orders", "error", err)
+			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to search orders"})
+		}
+
+		response := searchResponse{Orders: page.Orders}
+		if page.Next != nil {
+			response.NextCursor = page.Next.Encode()
+		}
+		log.WithContext(ctx).Debug("orders searched", "sort", search.Sort, "orders", len(page.Orders), "more", page.Next != nil)
+		return ctx.JSON(response)
+	}
+}
+
+func parseOrderSearch(ctx fiber.Ctx) (domain.OrderSearch, error) {
+	var err error
+	search := domain.OrderSearch{
+		Filter: domain.OrderFilter{
+			CustomerID:      ctx.Query("customer_id"),
+			TrackNumber:     ctx.Query("track_number"),
+			DeliveryService: ctx.Query("delivery_service"),
+			Locale:          ctx.Query("locale"),
+			PaymentProvider: ctx.Query("payment_provider"),
+			PaymentCurrency: ctx.Query("payment_currency"),
+			ItemBrand:       ctx.Query("brand"),
+		},
+		Limit: domain.DefaultSearchLimit,
+	}
+
+	if search.Sort, err = domain.ParseOrderSort(ctx.Query("sort")); err != nil {
+		return search, err
+	}
+	if value := ctx.Query("limit"); value != "" {
+		if search.Limit, err = strconv.Atoi(value); err != nil {
+			return search, fmt.Errorf("%w: limit must be an integer", domain.ErrInvalidOrderSearch)
+		}
+	}
+	if value := ctx.Query("nm_id"); value != "" {
+		if search.Filter.ItemNmID, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return search, fmt.Errorf("%w: nm_id must be an integer", domain.ErrInvalidOrderSearch)
+		}
+	}
+	if search.Filter.DateFrom, err = parseSearchTime("date_from", ctx.Query("date_from")); err != nil {
+		return search, err
+	}
+	if search.Filter.DateTo, err = parseSearchTime("date_to", ctx.Query("date_to")); err != nil {
+		return search, err
+	}
+	if token := ctx.Query("cursor"); token != "" {
+		if search.After, err = domain.DecodeOrderCursor(token, search.Sort); err != nil {
+			return search, err
+		}
+	}
+
+	return search, search.Validate()
+}
+
+// parseSearchTime accepts RFC 3339 timestamps and plain dates, read as UTC midnight.
+func parseSearchTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if parsed, err := time.Parse(layout, value); err == nil {
+			return parsed, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %s must be an RFC 3339 timestamp or a YYYY-MM-DD date", domain.ErrInvalidOrderSearch, name)
+}
@@ -6,7 +6,9 @@ import (
 	"net"
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/metrics"
 	"github.com/gofiber/fiber/v3"
+	"github.com/gofiber/fiber/v3/middleware/adaptor"
 	"github.com/gofiber/fiber/v3/middleware/static"
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
@@ -37,6 +39,8 @@ func NewHTTPServer(log logger.Logger, cfg config.ServerConfig) ports.HTTPServer
 
 	app := fiber.New(fiberCfg)
 
+	app.Use(TraceMiddleware())
+	app.Use(MetricsMiddleware())
 	app.Use(LoggingMiddleware(log))
 
 	staticCfg := static.Config{
@@ -53,18 +57,20 @@ func NewHTTPServer(log logger.Logger, cfg config.ServerConfig) ports.HTTPServer
 
 func (s *httpServer) Start(ctx context.Context) error {
 	addr := net.JoinHostPort(s.cfg.Host, fmt.Sprintf("%d", s.cfg.Port))
+
+	var listenConfig net.ListenConfig
+	listener, err := listenConfig.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen %s: %w", addr, err)
+	}
 	s.log.Info("starting HTTP server", "addr", addr)
 
 	go func() {
-		if err := s.app.Listen(addr); err != nil {
+		if err := s.app.Listener(listener); err != nil {
 			s.log.Error("HTTP server failed", "error", err)
 		}
 	}()
-
-	<-ctx.Done()
-	s.log.Info("shutting down HTTP server")
-
-	return fmt.Errorf("shutdown: %w", s.app.Shutdown())
+	return nil
 }
 
 func (s *httpServer) Stop(ctx context.Context) error {
@@ -72,7 +78,10 @@ func (s *httpServer) Stop(ctx context.Context) error {
 	shutdownCtx, cancel := context.WithTimeout(ctx, s.cfg.ShutdownTimeout)
 	defer cancel()
 
-	return fmt.Errorf("shutdown with context: %w", s.app.ShutdownWithContext(shutdownCtx))
+	if err := s.app.ShutdownWithContext(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown with context: %w", err)
+	}
+	return nil
 }
 
 func (s *httpServer) RegisterRoutes(orderHandler fiber.Handler) {
@@ -80,4 +89,37 @@ func (s *httpServer) RegisterRoutes(orderHandler fiber.Handler) {
 	s.app.Get("/health", func(c fiber.Ctx) error {
 		return c.JSON(fiber.Map{"status": "ok"})
 	})
+	s.app.Get("/metrics", adaptor.HTTPHandler(metrics.Handler()))
+}
+
+func (s *httpServer) RegisterLookupRoutes(trackHandler, customerHandler, itemHandler, productHandler fiber.Handler) {
+	s.app.Get("/order/track/:key", trackHandler)
+	s.app.Get("/customer/:key/orders", customerHandler)
+	s.app.Get("/item/:key/orders", itemHandler)
+	s.app.Get("/product/:key/orders", productHandler)
+}
+
+func (s *httpServer) RegisterSearchRoutes(searchHandler fiber.Handler) {
+	s.app.Get("/orders", searchHandler)
+}
+
+func (s *httpServer) RegisterIngestRoutes(singleHandler, batchHandler fiber.Handler) {
+	s.app.Post("/orders", singleHandler)
+	s.app.Post("/orders\\:batch", batchHandler)
+}
+
+func (s *httpServer) RegisterHealthRoutes(livenessHandler, readinessHandler fiber.Handler) {
+	s.app.Get("/livez", livenessHandler)
+	s.app.Get("/readyz", readinessHandler)
+}
+
+func (s *httpServer) RegisterAdminRoutes(replayStartHandler, replayStatusHandler fiber.Handler) {
+	if s.cfg.AdminToken == "" {
+		s.log.Warn("admin routes disabled, server.admin_token is not set")
+		return
+	}
+
+	admin := s.app.Group("/admin", AdminAuthMiddleware(s.cfg.AdminToken, s.log))
+	admin.Post("/replay", replayStartHandler)
+	admin.Get("/replay/:id", replayStatusHandler)
 }
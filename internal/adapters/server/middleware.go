@@ -1,13 +1,92 @@
 package server
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/metrics"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
 	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
+const HeaderTraceParent = "Traceparent"
+
+type localsKey string
+
+const requestContextKey localsKey = "request_context"
+
+var tracer = tracing.Tracer("adapters/server")
+
+// RequestContext returns the context prepared by TraceMiddleware.
+func RequestContext(ctx fiber.Ctx) context.Context {
+	if requestCtx, ok := ctx.Locals(requestContextKey).(context.Context); ok {
+		return requestCtx
+	}
+	return ctx
+}
+
+func TraceMiddleware() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		carrier := propagation.HeaderCarrier{}
+		for key, value := range ctx.Request().Header.All() {
+			carrier.Set(string(key), string(value))
+		}
+
+		requestCtx := otel.GetTextMapPropagator().Extract(context.Context(ctx), carrier)
+		requestCtx, span := tracer.Start(requestCtx, ctx.Method()+" "+ctx.Path(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", ctx.Method()),
+				attribute.String("url.path", ctx.Path()),
+				attribute.String("client.address", ctx.IP()),
+			))
+		defer span.End()
+
+		traceID := strings.TrimSpace(ctx.Get(fiber.HeaderXRequestID))
+		if !logger.IsValidTraceID(traceID) {
+			if spanContext := span.SpanContext(); spanContext.HasTraceID() {
+				traceID = spanContext.TraceID().String()
+			} else if parentID, ok := logger.TraceIDFromTraceParent(ctx.Get(HeaderTraceParent)); ok {
+				traceID = parentID
+			} else {
+				traceID = logger.NewTraceID()
+			}
+		}
+
+		ctx.Locals(logger.TraceIDKey, traceID)
+		ctx.Locals(requestContextKey, logger.WithTraceID(requestCtx, traceID))
+		ctx.Set(fiber.HeaderXRequestID, traceID)
+
+		err := ctx.Next()
+
+		status := ctx.Response().StatusCode()
+		span.SetName(ctx.Method() + " " + ctx.Route().Path)
+		span.SetAttributes(
+			attribute.String("http.route", ctx.Route().Path),
+			attribute.Int("http.response.status_code", status),
+		)
+		if err != nil {
+			tracing.RecordError(span, err)
+			return fmt.Errorf("trace middleware next: %w", err)
+		}
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+		return nil
+	}
+}
+
 func LoggingMiddleware(log logger.Logger) fiber.Handler {
 	return func(ctx fiber.Ctx) error {
 		start := time.Now()
@@ -15,7 +94,7 @@ func LoggingMiddleware(log logger.Logger) fiber.Handler {
 		duration := time.Since(start)
 
 		if err != nil || ctx.Response().StatusCode() >= 400 {
-			log.Info("HTTP request",
+			log.WithContext(ctx).Info("HTTP request",
 				"method", ctx.Method(),
 				"path", ctx.Path(),
 				"status", ctx.Response().StatusCode(),
@@ -30,3 +109,46 @@ func LoggingMiddleware(log logger.Logger) fiber.Handler {
 		return nil
 	}
 }
+
+func MetricsMiddleware() fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		start := time.Now()
+		err := ctx.Next()
+
+		// The error handler has not run yet, so the status comes from the error.
+		status := ctx.Response().StatusCode()
+		var fiberErr *fiber.Error
+		switch {
+		case errors.As(err, &fiberErr):
+			status = fiberErr.Code
+		case err != nil:
+			status = fiber.StatusInternalServerError
+		}
+
+		metrics.HTTPRequestDuration.WithLabelValues(
+			ctx.Method(),
+			ctx.Route().Path,
+			strconv.Itoa(status),
+		).Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			return fmt.Errorf("metrics middleware next: %w", err)
+		}
+		return nil
+	}
+}
+
+func AdminAuthMiddleware(token string, log logger.Logger) fiber.Handler {
+	return func(ctx fiber.Ctx) error {
+		provided, found := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			log.WithContext(ctx).Warn("unauthorized admin request", "path", ctx.Path(), "ip", ctx.IP())
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
+		}
+
+		if err := ctx.Next(); err != nil {
+			return fmt.Errorf("admin middleware next: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,90 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+)
+
+const cleanupInterval = 10 * time.Minute
+
+// Guard runs a request at most once per Idempotency-Key within the TTL.
+type Guard struct {
+	store       ports.IdempotencyStore
+	log         logger.Logger
+	lastCleanup time.Time
+	ttl         time.Duration
+	lockTimeout time.Duration
+	mu          sync.Mutex
+}
+
+func NewGuard(store ports.IdempotencyStore, cfg config.IngestConfig, log logger.Logger) *Guard {
+	return &Guard{
+		store:       store,
+		log:         log,
+		ttl:         cfg.IdempotencyTTL,
+		lockTimeout: cfg.IdempotencyLockTimeout,
+	}
+}
+
+// Do reports a stored response as replayed; server errors are not stored.
+func (g *Guard) Do(ctx context.Context, key, requestHash string, run func(context.Context) domain.IdempotentResponse) (domain.IdempotentResponse, bool, error) {
+	now := time.Now()
+	token, record, err := g.store.Claim(ctx, key, requestHash, now.Add(-g.ttl), now.Add(-g.lockTimeout))
+	if err != nil {
+		return domain.IdempotentResponse{}, false, err
+	}
+	if record != nil {
+		switch {
+		case record.RequestHash != requestHash:
+			return domain.IdempotentResponse{}, false, domain.ErrIdempotencyKeyReused
+		case record.Response == nil:
+			return domain.IdempotentResponse{}, false, domain.ErrRequestInProgress
+		default:
+			g.log.WithContext(ctx).Info("replaying idempotent response", "idempotency_key", key, "status", record.Response.StatusCode)
+			return *record.Response, true, nil
+		}
+	}
+
+	response := run(ctx)
+
+	storeCtx := context.WithoutCancel(ctx)
+	if response.StatusCode >= http.StatusInternalServerError {
+		if err := g.store.Release(storeCtx, key, token); err != nil {
+			g.log.WithContext(ctx).Warn("idempotency key stays locked until it times out", "idempotency_key", key, "error", err)
+		}
+	} else if err := g.store.Complete(storeCtx, key, token, response); errors.Is(err, domain.ErrIdempotencyClaimLost) {
+		g.log.WithContext(ctx).Warn("idempotency key was taken over after the lock timeout, response not stored", "idempotency_key", key)
+	} else if err != nil {
+		g.log.WithContext(ctx).Warn("response not stored, a retry will run the request again", "idempotency_key", key, "error", err)
+	}
+
+	g.cleanup(storeCtx)
+	return response, false, nil
+}
+
+func (g *Guard) cleanup(ctx context.Context) {
+	g.mu.Lock()
+	if time.Since(g.lastCleanup) < cleanupInterval {
+		g.mu.Unlock()
+		return
+	}
+	g.lastCleanup = time.Now()
+	g.mu.Unlock()
+
+	deleted, err := g.store.DeleteExpired(ctx, time.Now().Add(-g.ttl))
+	if err != nil {
+		g.log.Warn("failed to clean up expired idempotency keys", "error", err)
+		return
+	}
+	if deleted > 0 {
+		g.log.Info("expired idempotency keys cleaned up", "deleted", deleted)
+	}
+}
@@ -0,0 +1,200 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"go.uber.org/zap"
+)
+
+type storedKey struct {
+	record domain.IdempotencyRecord
+	token  string
+}
+
+// fakeStore follows the takeover and fencing rules of the Postgres store.
+type fakeStore struct {
+	keys   map[string]*storedKey
+	issued int
+	mu     sync.Mutex
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{keys: make(map[string]*storedKey)}
+}
+
+func (s *fakeStore) Claim(_ context.Context, key, requestHash string, expiredBefore, abandonedBefore time.Time) (string, *domain.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, ok := s.keys[key]; ok {
+		createdAt := stored.record.CreatedAt
+		if !createdAt.Before(expiredBefore) && (stored.record.Response != nil || !createdAt.Before(abandonedBefore)) {
+			record := stored.record
+			return "", &record, nil
+		}
+	}
+	s.issued++
+	token := strconv.Itoa(s.issued)
+	s.keys[key] = &storedKey{record: domain.IdempotencyRecord{RequestHash: requestHash, CreatedAt: time.Now()}, token: token}
+	return token, nil, nil
+}
+
+func (s *fakeStore) Complete(_ context.Context, key, token string, response domain.IdempotentResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.keys[key]
+	if !ok || stored.token != token || stored.record.Response != nil {
+		return domain.ErrIdempotencyClaimLost
+	}
+	stored.record.Response = &response
+	return nil
+}
+
+func (s *fakeStore) Release(_ context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, ok := s.keys[key]; ok && stored.token == token && stored.record.Response == nil {
+		delete(s.keys, key)
+	}
+	return nil
+}
+
+func (s *fakeStore) DeleteExpired(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// age moves a claim into the past, as if its request started d ago.
+func (s *fakeStore) age(key string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[key].record.CreatedAt = s.keys[key].record.CreatedAt.Add(-d)
+}
+
+func newTestGuard(store *fakeStore) *Guard {
+	zapLogger := zap.NewNop()
+	return NewGuard(store, config.IngestConfig{
+		IdempotencyTTL:         time.Hour,
+		IdempotencyLockTimeout: time.Minute,
+	}, &logger.ZapLogger{Logger: zapLogger, Sugared: zapLogger.Sugar()})
+}
+
+// counter answers with a fixed response and counts how often it ran.
+type counter struct {
+	response domain.IdempotentResponse
+	runs     int
+}
+
+func (c *counter) run(context.Context) domain.IdempotentResponse {
+	c.runs++
+	return c.response
+}
+
+func TestGuardReplaysStoredResponse(t *testing.T) {
+	guard := newTestGuard(newFakeStore())
+	request := &counter{response: domain.IdempotentResponse{StatusCode: http.StatusOK, Body: []byte(`{"status":"saved"}`)}}
+
+	first, replayed, err := guard.Do(t.Context(), "key", "hash", request.run)
+	if err != nil || replayed {
+		t.Fatalf("first Do() = replayed %v, error %v, want a fresh run", replayed, err)
+	}
+	second, replayed, err := guard.Do(t.Context(), "key", "hash", request.run)
+	if err != nil || !replayed {
+		t.Fatalf("retry Do() = replayed %v, error %v, want a replay", replayed, err)
+	}
+	if request.runs != 1 {
+		t.Fatalf("request ran %d times, want once", request.runs)
+	}
+	if second.StatusCode != first.StatusCode || string(second.Body) != string(first.Body) {
+		t.Fatalf("replayed %d %s, want %d %s", second.StatusCode, second.Body, first.StatusCode, first.Body)
+	}
+}
+
+func TestGuardRejectsReusedKey(t *testing.T) {
+	guard := newTestGuard(newFakeStore())
+	request := &counter{response: domain.IdempotentResponse{StatusCode: http.StatusOK}}
+
+	if _, _, err := guard.Do(t.Context(), "key", "hash", request.run); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if _, _, err := guard.Do(t.Context(), "key", "other-hash", request.run); !errors.Is(err, domain.ErrIdempotencyKeyReused) {
+		t.Fatalf("Do() with another request error = %v, want ErrIdempotencyKeyReused", err)
+	}
+	if request.runs != 1 {
+		t.Fatalf("request ran %d times, want once", request.runs)
+	}
+}
+
+func TestGuardReportsRequestInProgress(t *testing.T) {
+	guard := newTestGuard(newFakeStore())
+
+	_, _, err := guard.Do(t.Context(), "key", "hash", func(ctx context.Context) domain.IdempotentResponse {
+		_, _, err := guard.Do(ctx, "key", "hash", (&counter{}).run)
+		if !errors.Is(err, domain.ErrRequestInProgress) {
+			t.Errorf("concurrent Do() error = %v, want ErrRequestInProgress", err)
+		}
+		return domain.IdempotentResponse{StatusCode: http.StatusOK}
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+}
+
+func TestGuardRunsAgainAfterServerError(t *testing.T) {
+	guard := newTestGuard(newFakeStore())
+	request := &counter{response: domain.IdempotentResponse{StatusCode: http.StatusServiceUnavailable}}
+
+	for range 2 {
+		if _, replayed, err := guard.Do(t.Context(), "key", "hash", request.run); err != nil || replayed {
+			t.Fatalf("Do() = replayed %v, error %v, want a fresh run", replayed, err)
+		}
+	}
+	if request.runs != 2 {
+		t.Fatalf("request ran %d times, want a retry after a server error", request.runs)
+	}
+}
+
+func TestGuardKeepsClaimOfTakeover(t *testing.T) {
+	store := newFakeStore()
+	guard := newTestGuard(store)
+	var takeoverToken string
+
+	_, _, err := guard.Do(t.Context(), "key", "hash", func(ctx context.Context) domain.IdempotentResponse {
+		store.age("key", 2*time.Minute)
+		token, record, err := store.Claim(ctx, "key", "hash", time.Now().Add(-time.Hour), time.Now().Add(-time.Minute))
+		if err != nil || record != nil {
+			t.Fatalf("takeover Claim() = %+v, %v, want the key", record, err)
+		}
+		takeoverToken = token
+		return domain.IdempotentResponse{StatusCode: http.StatusOK, Body: []byte(`{"run":"first"}`)}
+	})
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	if _, _, err := guard.Do(t.Context(), "key", "hash", (&counter{}).run); !errors.Is(err, domain.ErrRequestInProgress) {
+		t.Fatalf("retry Do() error = %v, want ErrRequestInProgress while the takeover runs", err)
+	}
+
+	takeover := domain.IdempotentResponse{StatusCode: http.StatusOK, Body: []byte(`{"run":"takeover"}`)}
+	if err := store.Complete(t.Context(), "key", takeoverToken, takeover); err != nil {
+		t.Fatalf("takeover Complete() error = %v", err)
+	}
+	response, replayed, err := guard.Do(t.Context(), "key", "hash", (&counter{}).run)
+	if err != nil || !replayed {
+		t.Fatalf("retry Do() = replayed %v, error %v, want a replay", replayed, err)
+	}
+	if string(response.Body) != string(takeover.Body) {
+		t.Fatalf("replayed %s, want the response of the request that took the key over", response.Body)
+	}
+}
@@ -0,0 +1,133 @@
+package invalidation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/metrics"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/retry"
+)
+
+var (
+	ErrListenerAlreadyStarted = errors.New("cache invalidation listener already started")
+	ErrListenerNotStarted     = errors.New("cache invalidation listener not started")
+)
+
+// Listener applies order changes to the cache and re-warms it after a reconnect.
+type Listener struct {
+	feed    ports.OrderChangeFeed
+	cache   ports.Cache
+	repo    ports.OrderRepository
+	backoff *retry.Policy
+	log     logger.Logger
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func NewListener(feed ports.OrderChangeFeed, cache ports.Cache, repo ports.OrderRepository, cfg config.CacheInvalidationConfig, log logger.Logger) *Listener {
+	return &Listener{
+		feed:  feed,
+		cache: cache,
+		repo:  repo,
+		backoff: retry.NewPolicy(config.RetryConfig{
+			BaseBackoff: cfg.BaseBackoff,
+			MaxBackoff:  cfg.MaxBackoff,
+			Jitter:      0.2,
+		}, nil),
+		log: log,
+	}
+}
+
+// Start returns once the first subscription is active or has failed.
+func (l *Listener) Start(ctx context.Context) error {
+	if l.done != nil {
+		return ErrListenerAlreadyStarted
+	}
+
+	listenCtx, cancel := context.WithCancel(ctx)
+	l.cancel = cancel
+	l.done = make(chan struct{})
+	firstAttempt := make(chan struct{})
+
+	go func() {
+		defer close(l.done)
+		defer func() {
+			if rec := recover(); rec != nil {
+				l.log.Error("panic in cache invalidation listener", "panic", fmt.Sprintf("%v", rec))
+			}
+		}()
+		l.run(listenCtx, sync.OnceFunc(func() { close(firstAttempt) }))
+	}()
+
+	select {
+	case <-firstAttempt:
+	case <-ctx.Done():
+	}
+
+	l.log.Info("cache invalidation listener started")
+	return nil
+}
+
+func (l *Listener) run(ctx context.Context, attempted func()) {
+	defer attempted()
+
+	missed := false
+	for attempt := 1; ctx.Err() == nil; attempt++ {
+		err := l.feed.Listen(ctx, func(ctx context.Context) {
+			attempted()
+			if missed {
+				l.resync(ctx)
+			}
+			missed, attempt = false, 0
+		}, l.apply)
+		attempted()
+		if ctx.Err() != nil {
+			return
+		}
+
+		missed = true
+		metrics.CacheListenerReconnects.Inc()
+		delay := l.backoff.Backoff(attempt)
+		l.log.Warn("cache invalidation listener disconnected, reconnecting", "error", err, "retry_in", delay)
+		if err := retry.Sleep(ctx, delay); err != nil {
+			return
+		}
+	}
+}
+
+func (l *Listener) apply(ctx context.Context, change domain.OrderChange) {
+	metrics.CacheInvalidations.Inc()
+	l.cache.Invalidate(ctx, change)
+}
+
+func (l *Listener) resync(ctx context.Context) {
+	l.log.Info("re-warming the cache after missed order change notifications")
+	if err := l.cache.RestoreFromDB(ctx, l.repo); err != nil {
+		metrics.CacheResyncs.WithLabelValues(metrics.StatusError).Inc()
+		l.log.Error("failed to re-warm the cache, stale orders may be served until they expire", "error", err)
+		return
+	}
+	metrics.CacheResyncs.WithLabelValues(metrics.StatusOK).Inc()
+}
+
+func (l *Listener) Stop(ctx context.Context) error {
+	if l.done == nil {
+		return ErrListenerNotStarted
+	}
+
+	l.cancel()
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+		l.log.Warn("cache invalidation listener did not stop in time")
+	}
+
+	l.log.Info("cache invalidation listener stopped")
+	return nil
+}
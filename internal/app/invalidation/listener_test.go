@@ -0,0 +1,103 @@
+package invalidation
+
+import (
+	"context"
+	"errors"
+	"io"
+	"iter"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/cache"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"go.uber.org/zap"
+)
+
+var errConnectionLost = errors.New("connection lost")
+
+// flakyFeed fails the first subscription and keeps the second until stopped.
+type flakyFeed struct {
+	resynced chan struct{}
+	calls    atomic.Int32
+}
+
+func (f *flakyFeed) Listen(ctx context.Context, subscribed func(context.Context), _ func(context.Context, domain.OrderChange)) error {
+	if f.calls.Add(1) == 1 {
+		return errConnectionLost
+	}
+	subscribed(ctx)
+	close(f.resynced)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+type fakeRepository struct {
+	ports.OrderRepository
+	orders []*domain.Order
+}
+
+func (r *fakeRepository) IterateRecent(context.Context, int, int) iter.Seq2[*domain.Order, error] {
+	return func(yield func(*domain.Order, error) bool) {
+		for _, order := range r.orders {
+			if !yield(order, nil) {
+				return
+			}
+		}
+	}
+}
+
+func testOrder(version int64) *domain.Order {
+	return &domain.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		CustomerID:  "test",
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		Version:     version,
+	}
+}
+
+func TestResyncOverwritesStaleRedisOrders(t *testing.T) {
+	zapLogger := zap.NewNop()
+	log := &logger.ZapLogger{Logger: zapLogger, Sugared: zapLogger.Sugar()}
+
+	server := miniredis.RunT(t)
+	orders, err := cache.NewRedisCache(config.CacheConfig{
+		Redis:  config.RedisConfig{Addr: server.Addr()},
+		Warmup: config.CacheWarmupConfig{Strategy: cache.WarmupRecent, PageSize: 10},
+	}, log)
+	if err != nil {
+		t.Fatalf("NewRedisCache() error = %v", err)
+	}
+	if closer, ok := orders.(io.Closer); ok {
+		t.Cleanup(func() { _ = closer.Close() })
+	}
+	orders.Set(t.Context(), testOrder(1))
+
+	feed := &flakyFeed{resynced: make(chan struct{})}
+	repo := &fakeRepository{orders: []*domain.Order{testOrder(2)}}
+	listener := NewListener(feed, orders, repo, config.CacheInvalidationConfig{
+		BaseBackoff: time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	}, log)
+
+	if err := listener.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	select {
+	case <-feed.resynced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener did not resubscribe")
+	}
+	if err := listener.Stop(t.Context()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	order, found := orders.Get(t.Context(), "b563feb7b2b84b6test")
+	if !found || order.Version != 2 {
+		t.Fatalf("after resync the cache holds %+v, want version 2", order)
+	}
+}
@@ -2,81 +2,211 @@ package order
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/metrics"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/retry"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = tracing.Tracer("app/order")
+
 var (
-	ErrInvalidJSON = errors.New("invalid JSON payload")
-	ErrSaveFailed  = errors.New("failed to save order after retries")
+	ErrSaveFailed   = errors.New("failed to save order after retries")
+	ErrEmptyPayload = errors.New("payload is empty")
 )
 
 type OrderService struct {
-	repo  ports.OrderRepository
-	cache ports.Cache
-	log   logger.Logger
+	repo       ports.OrderRepository
+	cache      ports.Cache
+	decoder    ports.OrderDecoder
+	savePolicy *retry.Policy
+	log        logger.Logger
 }
 
-func NewOrderService(repo ports.OrderRepository, cache ports.Cache, log logger.Logger) *OrderService {
+func NewOrderService(repo ports.OrderRepository, cache ports.Cache, decoder ports.OrderDecoder, savePolicy *retry.Policy, log logger.Logger) *OrderService {
 	return &OrderService{
-		repo:  repo,
-		cache: cache,
-		log:   log,
+		repo:       repo,
+		cache:      cache,
+		decoder:    decoder,
+		savePolicy: savePolicy,
+		log:        log,
 	}
 }
 
 func (s *OrderService) ProcessMessage(ctx context.Context, payload []byte) error {
-	if len(payload) == 0 {
-		s.log.Warn("received empty payload")
-		return nil
+	return s.HandleMessage(ctx, &domain.Message{Value: payload})
+}
+
+func (s *OrderService) HandleMessage(ctx context.Context, msg *domain.Message) (err error) {
+	start := time.Now()
+	ctx = logger.WithTraceID(ctx, msg.TraceID)
+	ctx, span := tracer.Start(ctx, "OrderService.ProcessMessage", trace.WithAttributes(attribute.Int("payload.size", len(msg.Value))))
+	defer func() {
+		metrics.ObserveSince(metrics.ProcessMessageDuration, start, metrics.Outcome(err))
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	order, err := s.decodeOrder(ctx, msg)
+	if err != nil {
+		return err
+	}
+	span.SetAttributes(attribute.String("order.uid", order.OrderUID))
+
+	return s.persistOrder(ctx, order)
+}
+
+func (s *OrderService) ProcessBatch(ctx context.Context, payloads [][]byte) []error {
+	msgs := make([]*domain.Message, len(payloads))
+	for i, payload := range payloads {
+		msgs[i] = &domain.Message{Value: payload}
+	}
+	return s.HandleBatch(ctx, msgs)
+}
+
+func (s *OrderService) HandleBatch(ctx context.Context, msgs []*domain.Message) []error {
+	ctx, span := tracer.Start(ctx, "OrderService.ProcessBatch", trace.WithAttributes(attribute.Int("batch.size", len(msgs))))
+	defer span.End()
+
+	results := make([]error, len(msgs))
+	orders := make([]*domain.Order, 0, len(msgs))
+	positions := make([]int, 0, len(msgs))
+
+	for i, msg := range msgs {
+		order, err := s.decodeOrder(logger.WithTraceID(ctx, msg.TraceID), msg)
+		if err != nil {
+			results[i] = err
+			continue
+		}
+		orders = append(orders, order)
+		positions = append(positions, i)
+	}
+
+	if len(orders) == 0 {
+		return results
+	}
+
+	err := s.saveOrdersWithRetry(ctx, orders)
+	if err == nil {
+		for _, order := range orders {
+			s.cache.Set(ctx, order)
+		}
+		s.log.WithContext(ctx).Info("order batch processed successfully",
+			"batch_size", len(msgs),
+			"saved", len(orders),
+			"rejected", len(msgs)-len(orders))
+		return results
 	}
 
-	order := domain.Order{}
-	if err := json.Unmarshal(payload, &order); err != nil {
-		s.log.Warn("failed to unmarshal JSON payload",
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		for _, position := range positions {
+			results[position] = fmt.Errorf("save order batch to DB: %w", ctxErr)
+		}
+		return results
+	}
+
+	if errors.Is(err, ErrSaveFailed) {
+		s.log.WithContext(ctx).Error("failed to save order batch after retry", "batch_size", len(orders), "error", err)
+		for _, position := range positions {
+			results[position] = domain.NewFailedError(domain.ReasonSaveFailed, fmt.Errorf("save order batch to DB: %w", err))
+		}
+		return results
+	}
+
+	s.log.WithContext(ctx).Warn("order batch rejected by DB, falling back to per-order saves", "batch_size", len(orders), "error", err)
+	for i, order := range orders {
+		results[positions[i]] = s.persistOrder(ctx, order)
+	}
+
+	return results
+}
+
+func (s *OrderService) ValidateMessage(ctx context.Context, msg *domain.Message) (*domain.Order, error) {
+	return s.decodeOrder(ctx, msg)
+}
+
+func (s *OrderService) SaveOrder(ctx context.Context, order *domain.Order) (err error) {
+	start := time.Now()
+	ctx, span := tracer.Start(ctx, "OrderService.SaveOrder", trace.WithAttributes(attribute.String("order.uid", order.OrderUID)))
+	defer func() {
+		metrics.ObserveSince(metrics.ProcessMessageDuration, start, metrics.Outcome(err))
+		tracing.RecordError(span, err)
+		span.End()
+	}()
+
+	return s.persistOrder(ctx, order)
+}
+
+func (s *OrderService) decodeOrder(ctx context.Context, msg *domain.Message) (*domain.Order, error) {
+	if len(msg.Value) == 0 {
+		s.log.WithContext(ctx).Warn("received empty payload")
+		return nil, domain.NewRejectedError(domain.ReasonEmptyPayload, ErrEmptyPayload)
+	}
+
+	contentType := msg.Header(domain.HeaderContentType)
+	order, err := s.decoder.Decode(ctx, contentType, msg.Value)
+	if err != nil {
+		s.log.WithContext(ctx).Warn("failed to decode payload",
 			"error", err,
-			"payload_size", len(payload),
-			"payload_preview", s.getPayloadPreview(payload))
-		return nil
+			"content_type", contentType,
+			"payload_size", len(msg.Value),
+			"payload_preview", s.getPayloadPreview(msg.Value))
+		return nil, err
 	}
 
-	s.log.Info("unmarshaled order", "order_uid", order.OrderUID, "sm_id", order.SmID)
+	s.log.WithContext(ctx).Info("decoded order", "order_uid", order.OrderUID, "sm_id", order.SmID, "content_type", contentType)
 
 	if order.OrderUID == "" {
-		s.log.Warn("unmarshaled order has empty order_uid, skipping")
-		return nil
+		s.log.WithContext(ctx).Warn("decoded order has empty order_uid, rejecting")
+		return nil, domain.NewRejectedError(domain.ReasonEmptyOrderUID, domain.NewFieldError("order_uid", ErrInvalidOrderUID))
 	}
 
-	if err := ValidateOrder(&order, s.log); err != nil {
-		s.log.Warn("order validation failed, skipping",
+	if err := ValidateOrder(order, s.log.WithContext(ctx)); err != nil {
+		s.log.WithContext(ctx).Warn("order validation failed, rejecting",
 			"order_uid", order.OrderUID,
 			"error", err)
-		return nil
+		return nil, domain.NewRejectedError(domain.ReasonValidationFailed, err)
 	}
 
-	if err := s.saveOrderWithRetry(ctx, &order); err != nil {
-		s.log.Error("failed to save order after retry",
+	return order, nil
+}
+
+func (s *OrderService) persistOrder(ctx context.Context, order *domain.Order) error {
+	if err := s.saveOrderWithRetry(ctx, order); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return fmt.Errorf("save order to DB: %w", ctxErr)
+		}
+		if !errors.Is(err, ErrSaveFailed) {
+			s.log.WithContext(ctx).Error("order rejected by DB, not retrying",
+				"order_uid", order.OrderUID,
+				"error", err)
+			return domain.NewRejectedError(domain.ReasonSaveRejected, fmt.Errorf("save order to DB: %w", err))
+		}
+		s.log.WithContext(ctx).Error("failed to save order after retry",
 			"order_uid", order.OrderUID,
 			"error", err)
-		return fmt.Errorf("save order to DB: %w", err)
+		return domain.NewFailedError(domain.ReasonSaveFailed, fmt.Errorf("save order to DB: %w", err))
 	}
 
 	orderFromDB, err := s.repo.GetOrder(ctx, order.OrderUID)
 	if err != nil {
-		s.log.Warn("failed to get order from DB after save, caching original",
+		s.log.WithContext(ctx).Warn("failed to get order from DB after save, caching original",
 			"order_uid", order.OrderUID,
 			"error", err)
-		s.cache.Set(&order)
+		s.cache.Set(ctx, order)
 	} else {
-		s.cache.Set(orderFromDB)
+		s.cache.Set(ctx, orderFromDB)
 	}
 
-	s.log.Info("order processed successfully",
+	s.log.WithContext(ctx).Info("order processed successfully",
 		"order_uid", order.OrderUID,
 		"items_count", len(order.Items))
 
@@ -92,19 +222,43 @@ func (s *OrderService) getPayloadPreview(payload []byte) string {
 }
 
 func (s *OrderService) saveOrderWithRetry(ctx context.Context, order *domain.Order) error {
-	const maxRetries = 2
+	err := s.savePolicy.Do(ctx, func(ctx context.Context) error {
+		return s.repo.SaveOrderTx(ctx, order)
+	}, func(attempt int, delay time.Duration, err error) {
+		s.log.WithContext(ctx).Warn("save order attempt failed, retrying",
+			"order_uid", order.OrderUID,
+			"attempt", attempt,
+			"max_attempts", s.savePolicy.MaxAttempts(),
+			"backoff", delay,
+			"error", err)
+	})
+	if err == nil {
+		return nil
+	}
 
-	for attempt := 0; attempt <= maxRetries; attempt++ {
-		if err := s.repo.SaveOrderTx(ctx, order); err == nil {
-			return nil
-		}
+	if errors.Is(err, retry.ErrAttemptsExhausted) {
+		return fmt.Errorf("%w: %w", ErrSaveFailed, err)
+	}
+	return fmt.Errorf("failed to save: %w", err)
+}
 
-		if attempt < maxRetries {
-			s.log.Warn("save order attempt failed, retrying",
-				"order_uid", order.OrderUID,
-				"attempt", attempt+1)
-		}
+func (s *OrderService) saveOrdersWithRetry(ctx context.Context, orders []*domain.Order) error {
+	err := s.savePolicy.Do(ctx, func(ctx context.Context) error {
+		return s.repo.SaveOrdersTx(ctx, orders)
+	}, func(attempt int, delay time.Duration, err error) {
+		s.log.WithContext(ctx).Warn("save order batch attempt failed, retrying",
+			"batch_size", len(orders),
+			"attempt", attempt,
+			"max_attempts", s.savePolicy.MaxAttempts(),
+			"backoff", delay,
+			"error", err)
+	})
+	if err == nil {
+		return nil
 	}
 
-	return fmt.Errorf("failed to save: %w", ErrSaveFailed)
+	if errors.Is(err, retry.ErrAttemptsExhausted) {
+		return fmt.Errorf("%w: %w", ErrSaveFailed, err)
+	}
+	return fmt.Errorf("failed to save batch: %w", err)
 }
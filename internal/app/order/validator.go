@@ -3,7 +3,6 @@ package order
 import (
 	"errors"
 	"fmt"
-	"slices"
 	"time"
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
@@ -25,20 +24,35 @@ const (
 	timeTolerance    = 1 * time.Minute
 )
 
+// requiredField is a string field named as in the JSON payload.
+type requiredField struct {
+	name  string
+	value string
+}
+
+func firstEmpty(fields []requiredField) (string, bool) {
+	for _, field := range fields {
+		if field.value == "" {
+			return field.name, true
+		}
+	}
+	return "", false
+}
+
 func validateDelivery(delivery *domain.Delivery, log logger.Logger) error {
 	if delivery == nil {
 		log.Warn("delivery is nil")
-		return ErrInvalidDelivery
+		return domain.NewFieldError("delivery", ErrInvalidDelivery)
 	}
 
-	requiredFields := []string{
-		delivery.Name, delivery.Phone, delivery.Zip,
-		delivery.City, delivery.Address, delivery.Region, delivery.Email,
+	requiredFields := []requiredField{
+		{"name", delivery.Name}, {"phone", delivery.Phone}, {"zip", delivery.Zip},
+		{"city", delivery.City}, {"address", delivery.Address}, {"region", delivery.Region}, {"email", delivery.Email},
 	}
 
-	if slices.Contains(requiredFields, "") {
-		log.Warn("incomplete delivery fields")
-		return ErrInvalidDelivery
+	if field, empty := firstEmpty(requiredFields); empty {
+		log.Warn("incomplete delivery fields", "field", field)
+		return domain.NewFieldError("delivery."+field, ErrInvalidDelivery)
 	}
 	return nil
 }
@@ -46,28 +60,34 @@ func validateDelivery(delivery *domain.Delivery, log logger.Logger) error {
 func validatePayment(payment *domain.Payment, log logger.Logger) error {
 	if payment == nil {
 		log.Warn("payment is nil")
-		return ErrInvalidPayment
+		return domain.NewFieldError("payment", ErrInvalidPayment)
 	}
 
-	if payment.Transaction == "" || payment.Currency == "" ||
-		payment.Provider == "" || payment.Bank == "" {
-		log.Warn("invalid payment string fields")
-		return ErrInvalidPayment
+	requiredFields := []requiredField{
+		{"transaction", payment.Transaction}, {"currency", payment.Currency},
+		{"provider", payment.Provider}, {"bank", payment.Bank},
+	}
+	if field, empty := firstEmpty(requiredFields); empty {
+		log.Warn("invalid payment string fields", "field", field)
+		return domain.NewFieldError("payment."+field, ErrInvalidPayment)
 	}
 
 	if payment.PaymentDt <= 0 {
 		log.Warn("invalid payment timestamp")
-		return ErrInvalidPayment
+		return domain.NewFieldError("payment.payment_dt", ErrInvalidPayment)
 	}
 
-	amounts := []float64{
-		payment.Amount, payment.DeliveryCost,
-		payment.GoodsTotal, payment.CustomFee,
+	amounts := []struct {
+		name  string
+		value float64
+	}{
+		{"amount", payment.Amount}, {"delivery_cost", payment.DeliveryCost},
+		{"goods_total", payment.GoodsTotal}, {"custom_fee", payment.CustomFee},
 	}
 	for _, amount := range amounts {
-		if amount < minPositiveValue {
-			log.Warn("invalid payment amount")
-			return ErrInvalidPayment
+		if amount.value < minPositiveValue {
+			log.Warn("invalid payment amount", "field", amount.name)
+			return domain.NewFieldError("payment."+amount.name, ErrInvalidPayment)
 		}
 	}
 	return nil
@@ -75,24 +95,34 @@ func validatePayment(payment *domain.Payment, log logger.Logger) error {
 
 func validateItems(orderUID string, items []domain.Item, log logger.Logger) error {
 	for itemIndex, item := range items {
+		field := func(name string) string {
+			return fmt.Sprintf("items[%d].%s", itemIndex, name)
+		}
+
 		if item.ChrtID <= 0 {
 			log.Warn("invalid item chrt_id", "order_uid", orderUID, "index", itemIndex, "chrt_id", item.ChrtID)
-			return ErrInvalidItem
+			return domain.NewFieldError(field("chrt_id"), ErrInvalidItem)
 		}
 
 		if item.Name == "" {
 			log.Warn("invalid item name", "order_uid", orderUID, "index", itemIndex)
-			return ErrInvalidItem
+			return domain.NewFieldError(field("name"), ErrInvalidItem)
 		}
 
 		if item.Price < minPositiveValue || item.TotalPrice < minPositiveValue {
 			log.Warn("invalid item price", "order_uid", orderUID, "index", itemIndex, "price", item.Price, "total_price", item.TotalPrice)
-			return ErrInvalidItem
+			if item.Price < minPositiveValue {
+				return domain.NewFieldError(field("price"), ErrInvalidItem)
+			}
+			return domain.NewFieldError(field("total_price"), ErrInvalidItem)
 		}
 
 		if item.Sale < minPositiveValue || item.Sale > maxSalePercent || item.Status < minPositiveValue {
 			log.Warn("invalid item sale or status", "order_uid", orderUID, "index", itemIndex, "sale", item.Sale, "status", item.Status)
-			return ErrInvalidItem
+			if item.Status < minPositiveValue {
+				return domain.NewFieldError(field("status"), ErrInvalidItem)
+			}
+			return domain.NewFieldError(field("sale"), ErrInvalidItem)
 		}
 	}
 	return nil
@@ -106,7 +136,7 @@ func ValidateOrder(order *domain.Order, log logger.Logger) error {
 
 	if order.OrderUID == "" {
 		log.Warn("invalid order: empty order_uid")
-		return ErrInvalidOrderUID
+		return domain.NewFieldError("order_uid", ErrInvalidOrderUID)
 	}
 
 	now := time.Now().UTC()
@@ -115,7 +145,7 @@ func ValidateOrder(order *domain.Order, log logger.Logger) error {
 
 	if orderTime.IsZero() {
 		log.Warn("invalid order: zero date_created", "order_uid", order.OrderUID)
-		return ErrInvalidDate
+		return domain.NewFieldError("date_created", ErrInvalidDate)
 	}
 
 	if orderTime.After(futureLimit) {
@@ -124,7 +154,7 @@ func ValidateOrder(order *domain.Order, log logger.Logger) error {
 			"date", order.DateCreated,
 			"server_time", now,
 			"tolerance_minutes", timeTolerance.Minutes())
-		return ErrInvalidDate
+		return domain.NewFieldError("date_created", ErrInvalidDate)
 	}
 
 	if err := validateDelivery(order.Delivery, log); err != nil {
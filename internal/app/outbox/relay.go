@@ -0,0 +1,144 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+)
+
+var (
+	ErrRelayAlreadyStarted = errors.New("outbox relay already started")
+	ErrRelayNotStarted     = errors.New("outbox relay not started")
+)
+
+const (
+	cleanupInterval     = 10 * time.Minute
+	defaultPollInterval = time.Second
+)
+
+type Relay struct {
+	store       ports.OutboxStore
+	publisher   ports.EventPublisher
+	log         logger.Logger
+	cancel      context.CancelFunc
+	done        chan struct{}
+	lastCleanup time.Time
+	interval    time.Duration
+	retention   time.Duration
+	batchSize   int
+}
+
+func NewRelay(store ports.OutboxStore, publisher ports.EventPublisher, cfg config.OutboxConfig, log logger.Logger) *Relay {
+	interval := cfg.PollInterval
+	if interval <= 0 {
+		log.Warn("outbox.poll_interval must be positive, using the default", "poll_interval", interval, "default", defaultPollInterval)
+		interval = defaultPollInterval
+	}
+
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		log:       log,
+		interval:  interval,
+		retention: cfg.Retention,
+		batchSize: max(cfg.BatchSize, 1),
+	}
+}
+
+func (r *Relay) Start(ctx context.Context) error {
+	if r.done != nil {
+		return ErrRelayAlreadyStarted
+	}
+
+	relayCtx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		defer func() {
+			if rec := recover(); rec != nil {
+				r.log.Error("panic in outbox relay", "panic", fmt.Sprintf("%v", rec))
+			}
+		}()
+		r.run(relayCtx)
+	}()
+
+	r.log.Info("outbox relay started", "poll_interval", r.interval, "batch_size", r.batchSize)
+	return nil
+}
+
+func (r *Relay) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.drain(ctx)
+			r.cleanup(ctx)
+		}
+	}
+}
+
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := r.store.PublishPending(ctx, r.batchSize, r.publisher.Publish)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				r.log.Error("failed to relay outbox events", "error", err)
+			}
+			return
+		}
+		if published > 0 {
+			r.log.Debug("outbox events relayed", "events", published)
+		}
+		if published < r.batchSize {
+			return
+		}
+	}
+}
+
+func (r *Relay) cleanup(ctx context.Context) {
+	if r.retention <= 0 || time.Since(r.lastCleanup) < cleanupInterval {
+		return
+	}
+	r.lastCleanup = time.Now()
+
+	deleted, err := r.store.DeletePublished(ctx, time.Now().Add(-r.retention))
+	if err != nil {
+		r.log.Warn("failed to clean up published outbox events", "error", err)
+		return
+	}
+	if deleted > 0 {
+		r.log.Info("published outbox events cleaned up", "deleted", deleted)
+	}
+}
+
+func (r *Relay) Stop(ctx context.Context) error {
+	if r.done == nil {
+		return ErrRelayNotStarted
+	}
+
+	r.cancel()
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		r.log.Warn("outbox relay did not stop in time")
+	}
+
+	if err := r.publisher.Close(); err != nil {
+		r.log.Error("failed to close event publisher", "error", err)
+		return fmt.Errorf("close event publisher: %w", err)
+	}
+
+	r.log.Info("outbox relay stopped")
+	return nil
+}
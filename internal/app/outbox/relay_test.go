@@ -0,0 +1,167 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"go.uber.org/zap"
+)
+
+var errBrokerUnavailable = errors.New("broker unavailable")
+
+// fakeStore hands out pending events in batches and keeps those whose publish failed.
+type fakeStore struct {
+	pending   []domain.OutboxEvent
+	published []int64
+	deleted   []time.Time
+	calls     int
+	mu        sync.Mutex
+}
+
+func newFakeStore(events int) *fakeStore {
+	store := &fakeStore{}
+	for i := range events {
+		store.pending = append(store.pending, domain.OutboxEvent{ID: int64(i + 1)})
+	}
+	return store
+}
+
+func (s *fakeStore) PublishPending(ctx context.Context, limit int, publish func(context.Context, []domain.OutboxEvent) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.calls++
+	batch := s.pending[:min(limit, len(s.pending))]
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := publish(ctx, batch); err != nil {
+		return 0, err
+	}
+	for _, event := range batch {
+		s.published = append(s.published, event.ID)
+	}
+	s.pending = s.pending[len(batch):]
+	return len(batch), nil
+}
+
+func (s *fakeStore) DeletePublished(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleted = append(s.deleted, before)
+	return 0, nil
+}
+
+type fakePublisher struct {
+	failures int
+	closed   bool
+}
+
+func (p *fakePublisher) Publish(context.Context, []domain.OutboxEvent) error {
+	if p.failures > 0 {
+		p.failures--
+		return errBrokerUnavailable
+	}
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	p.closed = true
+	return nil
+}
+
+func nopLogger() logger.Logger {
+	zapLogger := zap.NewNop()
+	return &logger.ZapLogger{Logger: zapLogger, Sugared: zapLogger.Sugar()}
+}
+
+func TestDrainPublishesFullBatchesUntilEmpty(t *testing.T) {
+	store := newFakeStore(5)
+	relay := NewRelay(store, &fakePublisher{}, config.OutboxConfig{PollInterval: time.Second, BatchSize: 2}, nopLogger())
+
+	relay.drain(t.Context())
+
+	if len(store.published) != 5 || len(store.pending) != 0 {
+		t.Fatalf("published %v, %d left pending; want all 5 published", store.published, len(store.pending))
+	}
+	if store.calls != 3 {
+		t.Fatalf("PublishPending called %d times, want 3", store.calls)
+	}
+}
+
+func TestDrainKeepsFailedEventsForNextPoll(t *testing.T) {
+	store := newFakeStore(3)
+	relay := NewRelay(store, &fakePublisher{failures: 1}, config.OutboxConfig{PollInterval: time.Second, BatchSize: 10}, nopLogger())
+
+	relay.drain(t.Context())
+	if len(store.published) != 0 || len(store.pending) != 3 {
+		t.Fatalf("published %v, %d left pending; want the failed batch kept", store.published, len(store.pending))
+	}
+
+	relay.drain(t.Context())
+	if len(store.published) != 3 || len(store.pending) != 0 {
+		t.Fatalf("published %v, %d left pending; want the batch published on the next poll", store.published, len(store.pending))
+	}
+}
+
+func TestCleanupDeletesPublishedEventsPastRetention(t *testing.T) {
+	store := newFakeStore(0)
+	relay := NewRelay(store, &fakePublisher{}, config.OutboxConfig{PollInterval: time.Second, Retention: time.Hour}, nopLogger())
+
+	relay.cleanup(t.Context())
+	relay.cleanup(t.Context())
+
+	if len(store.deleted) != 1 {
+		t.Fatalf("DeletePublished called %d times, want once per cleanup interval", len(store.deleted))
+	}
+	if cutoff := time.Since(store.deleted[0]); cutoff < time.Hour || cutoff > time.Hour+time.Minute {
+		t.Fatalf("deleted events published before %s ago, want the 1h retention", cutoff)
+	}
+
+	disabled := newFakeStore(0)
+	NewRelay(disabled, &fakePublisher{}, config.OutboxConfig{PollInterval: time.Second}, nopLogger()).cleanup(t.Context())
+	if len(disabled.deleted) != 0 {
+		t.Fatal("published events deleted without a retention")
+	}
+}
+
+func TestRelayWithoutPollIntervalUsesDefault(t *testing.T) {
+	store := newFakeStore(1)
+	publisher := &fakePublisher{}
+	relay := NewRelay(store, publisher, config.OutboxConfig{}, nopLogger())
+	if relay.interval != defaultPollInterval {
+		t.Fatalf("poll interval = %s, want the default %s", relay.interval, defaultPollInterval)
+	}
+
+	if err := relay.Start(t.Context()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * defaultPollInterval)
+	for {
+		store.mu.Lock()
+		published := len(store.published)
+		store.mu.Unlock()
+		if published == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("relay did not publish the pending event")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := relay.Stop(t.Context()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if !publisher.closed {
+		t.Fatal("publisher not closed on stop")
+	}
+}
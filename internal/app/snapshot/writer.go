@@ -0,0 +1,101 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+)
+
+var (
+	ErrWriterAlreadyStarted = errors.New("cache snapshot writer already started")
+	ErrWriterNotStarted     = errors.New("cache snapshot writer not started")
+)
+
+// Writer saves the cache every interval and once more when stopped.
+type Writer struct {
+	cache    ports.CacheSnapshotter
+	log      logger.Logger
+	cancel   context.CancelFunc
+	done     chan struct{}
+	path     string
+	interval time.Duration
+}
+
+func NewWriter(cache ports.CacheSnapshotter, cfg config.CacheSnapshotConfig, log logger.Logger) *Writer {
+	return &Writer{
+		cache:    cache,
+		log:      log,
+		path:     cfg.Path,
+		interval: cfg.Interval,
+	}
+}
+
+func (w *Writer) Start(ctx context.Context) error {
+	if w.done != nil {
+		return ErrWriterAlreadyStarted
+	}
+
+	writerCtx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+	w.done = make(chan struct{})
+
+	go func() {
+		defer close(w.done)
+		defer func() {
+			if rec := recover(); rec != nil {
+				w.log.Error("panic in cache snapshot writer", "panic", fmt.Sprintf("%v", rec))
+			}
+		}()
+		w.run(writerCtx)
+	}()
+
+	w.log.Info("cache snapshot writer started", "path", w.path, "interval", w.interval)
+	return nil
+}
+
+func (w *Writer) run(ctx context.Context) {
+	if w.interval <= 0 {
+		<-ctx.Done()
+		return
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := w.cache.SaveSnapshot(ctx, w.path); err != nil {
+				w.log.Error("failed to write cache snapshot", "path", w.path, "error", err)
+			}
+		}
+	}
+}
+
+// Stop must run after everything that updates the cache has stopped.
+func (w *Writer) Stop(ctx context.Context) error {
+	if w.done == nil {
+		return ErrWriterNotStarted
+	}
+
+	w.cancel()
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		w.log.Warn("cache snapshot writer did not stop in time")
+		return fmt.Errorf("stop cache snapshot writer: %w", ctx.Err())
+	}
+
+	if err := w.cache.SaveSnapshot(ctx, w.path); err != nil {
+		return fmt.Errorf("write final cache snapshot: %w", err)
+	}
+	w.log.Info("cache snapshot writer stopped")
+	return nil
+}
@@ -5,7 +5,12 @@ import "time"
 type Config struct {
 	Database DatabaseConfig `yaml:"database" mapstructure:"database"`
 	Server   ServerConfig   `yaml:"server" mapstructure:"server"`
+	Cache    CacheConfig    `yaml:"cache" mapstructure:"cache"`
 	Kafka    KafkaConfig    `yaml:"kafka" mapstructure:"kafka"`
+	Retry    RetryConfig    `yaml:"retry" mapstructure:"retry"`
+	Outbox   OutboxConfig   `yaml:"outbox" mapstructure:"outbox"`
+	Codec    CodecConfig    `yaml:"codec" mapstructure:"codec"`
+	Tracing  TracingConfig  `yaml:"tracing" mapstructure:"tracing"`
 	Logger   LoggerConfig   `yaml:"logger" mapstructure:"logger"`
 	Shutdown ShutdownConfig `yaml:"shutdown" mapstructure:"shutdown"`
 }
@@ -27,7 +32,9 @@ type DatabaseConfig struct {
 }
 
 type ServerConfig struct {
+	Ingest          IngestConfig  `yaml:"ingest" mapstructure:"ingest"`
 	Host            string        `yaml:"host" mapstructure:"host"`
+	AdminToken      string        `yaml:"admin_token" mapstructure:"admin_token"`
 	Timeout         time.Duration `yaml:"timeout" mapstructure:"timeout"`
 	IdleTimeout     time.Duration `yaml:"idle_timeout" mapstructure:"idle_timeout"`
 	ReadTimeout     time.Duration `yaml:"read_timeout" mapstructure:"read_timeout"`
@@ -35,18 +42,138 @@ type ServerConfig struct {
 	Port            int           `yaml:"port" mapstructure:"port"`
 }
 
+type IngestConfig struct {
+	IdempotencyTTL         time.Duration `yaml:"idempotency_ttl" mapstructure:"idempotency_ttl"`
+	IdempotencyLockTimeout time.Duration `yaml:"idempotency_lock_timeout" mapstructure:"idempotency_lock_timeout"`
+	MaxBatchSize           int           `yaml:"max_batch_size" mapstructure:"max_batch_size"`
+}
+
+type CacheConfig struct {
+	Redis        RedisConfig             `yaml:"redis" mapstructure:"redis"`
+	Invalidation CacheInvalidationConfig `yaml:"invalidation" mapstructure:"invalidation"`
+	Backend      string                  `yaml:"backend" mapstructure:"backend"`
+	Warmup       CacheWarmupConfig       `yaml:"warmup" mapstructure:"warmup"`
+	Snapshot     CacheSnapshotConfig     `yaml:"snapshot" mapstructure:"snapshot"`
+	Policy       string                  `yaml:"policy" mapstructure:"policy"`
+	TTL          time.Duration           `yaml:"ttl" mapstructure:"ttl"`
+	NegativeTTL  time.Duration           `yaml:"negative_ttl" mapstructure:"negative_ttl"`
+	MaxBytes     int64                   `yaml:"max_bytes" mapstructure:"max_bytes"`
+	MaxEntries   int                     `yaml:"max_entries" mapstructure:"max_entries"`
+}
+
+type RedisConfig struct {
+	Addr        string        `yaml:"addr" mapstructure:"addr"`
+	Username    string        `yaml:"username" mapstructure:"username"`
+	Password    string        `yaml:"password" mapstructure:"password"`
+	KeyPrefix   string        `yaml:"key_prefix" mapstructure:"key_prefix"`
+	DialTimeout time.Duration `yaml:"dial_timeout" mapstructure:"dial_timeout"`
+	Timeout     time.Duration `yaml:"timeout" mapstructure:"timeout"`
+	LocalTTL    time.Duration `yaml:"local_ttl" mapstructure:"local_ttl"`
+	DB          int           `yaml:"db" mapstructure:"db"`
+	PoolSize    int           `yaml:"pool_size" mapstructure:"pool_size"`
+	TLS         bool          `yaml:"tls" mapstructure:"tls"`
+}
+
+type CacheInvalidationConfig struct {
+	PingInterval time.Duration `yaml:"ping_interval" mapstructure:"ping_interval"`
+	BaseBackoff  time.Duration `yaml:"base_backoff" mapstructure:"base_backoff"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" mapstructure:"max_backoff"`
+	Enabled      bool          `yaml:"enabled" mapstructure:"enabled"`
+}
+
+type CacheWarmupConfig struct {
+	Strategy    string        `yaml:"strategy" mapstructure:"strategy"`
+	HotListPath string        `yaml:"hot_list_path" mapstructure:"hot_list_path"`
+	Window      time.Duration `yaml:"window" mapstructure:"window"`
+	Limit       int           `yaml:"limit" mapstructure:"limit"`
+	PageSize    int           `yaml:"page_size" mapstructure:"page_size"`
+	Async       bool          `yaml:"async" mapstructure:"async"`
+}
+
+type CacheSnapshotConfig struct {
+	Path     string        `yaml:"path" mapstructure:"path"`
+	Interval time.Duration `yaml:"interval" mapstructure:"interval"`
+	MaxAge   time.Duration `yaml:"max_age" mapstructure:"max_age"`
+}
+
 type KafkaConfig struct {
-	Brokers          []string      `yaml:"brokers" mapstructure:"brokers"`
-	Topic            string        `yaml:"topic" mapstructure:"topic"`
-	GroupID          string        `yaml:"group_id" mapstructure:"group_id"`
-	AutoOffsetReset  string        `yaml:"auto_offset_reset" mapstructure:"auto_offset_reset"`
-	SessionTimeout   time.Duration `yaml:"session_timeout" mapstructure:"session_timeout"`
-	MaxWait          time.Duration `yaml:"max_wait" mapstructure:"max_wait"`
-	CommitInterval   time.Duration `yaml:"commit_interval" mapstructure:"commit_interval"`
-	MinBytes         int           `yaml:"min_bytes" mapstructure:"min_bytes"`
-	MaxBytes         int           `yaml:"max_bytes" mapstructure:"max_bytes"`
-	MaxRetries       int           `yaml:"max_retries" mapstructure:"max_retries"`
-	EnableAutoCommit bool          `yaml:"enable_auto_commit" mapstructure:"enable_auto_commit"`
+	SASL             KafkaSASLConfig    `yaml:"sasl" mapstructure:"sasl"`
+	TLS              KafkaTLSConfig     `yaml:"tls" mapstructure:"tls"`
+	Brokers          []string           `yaml:"brokers" mapstructure:"brokers"`
+	Topics           []KafkaTopicConfig `yaml:"topics" mapstructure:"topics"`
+	Topic            string             `yaml:"topic" mapstructure:"topic"`
+	GroupID          string             `yaml:"group_id" mapstructure:"group_id"`
+	AutoOffsetReset  string             `yaml:"auto_offset_reset" mapstructure:"auto_offset_reset"`
+	DeadLetterTopic  string             `yaml:"dead_letter_topic" mapstructure:"dead_letter_topic"`
+	EventsTopic      string             `yaml:"events_topic" mapstructure:"events_topic"`
+	OrderingKey      string             `yaml:"ordering_key" mapstructure:"ordering_key"`
+	CommitStrategy   string             `yaml:"commit_strategy" mapstructure:"commit_strategy"`
+	SessionTimeout   time.Duration      `yaml:"session_timeout" mapstructure:"session_timeout"`
+	MaxWait          time.Duration      `yaml:"max_wait" mapstructure:"max_wait"`
+	CommitInterval   time.Duration      `yaml:"commit_interval" mapstructure:"commit_interval"`
+	BatchLinger      time.Duration      `yaml:"batch_linger" mapstructure:"batch_linger"`
+	MinBytes         int                `yaml:"min_bytes" mapstructure:"min_bytes"`
+	MaxBytes         int                `yaml:"max_bytes" mapstructure:"max_bytes"`
+	Workers          int                `yaml:"workers" mapstructure:"workers"`
+	BatchSize        int                `yaml:"batch_size" mapstructure:"batch_size"`
+	EnableAutoCommit bool               `yaml:"enable_auto_commit" mapstructure:"enable_auto_commit"`
+}
+
+type KafkaTopicConfig struct {
+	Name    string `yaml:"name" mapstructure:"name"`
+	Handler string `yaml:"handler" mapstructure:"handler"`
+}
+
+type KafkaTLSConfig struct {
+	CAFile             string `yaml:"ca_file" mapstructure:"ca_file"`
+	CertFile           string `yaml:"cert_file" mapstructure:"cert_file"`
+	KeyFile            string `yaml:"key_file" mapstructure:"key_file"`
+	Enabled            bool   `yaml:"enabled" mapstructure:"enabled"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" mapstructure:"insecure_skip_verify"`
+}
+
+type KafkaSASLConfig struct {
+	Mechanism string `yaml:"mechanism" mapstructure:"mechanism"`
+	Username  string `yaml:"username" mapstructure:"username"`
+	Password  string `yaml:"password" mapstructure:"password"`
+}
+
+type OutboxConfig struct {
+	PollInterval time.Duration `yaml:"poll_interval" mapstructure:"poll_interval"`
+	Retention    time.Duration `yaml:"retention" mapstructure:"retention"`
+	BaseBackoff  time.Duration `yaml:"base_backoff" mapstructure:"base_backoff"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" mapstructure:"max_backoff"`
+	BatchSize    int           `yaml:"batch_size" mapstructure:"batch_size"`
+	MaxAttempts  int           `yaml:"max_attempts" mapstructure:"max_attempts"`
+	Enabled      bool          `yaml:"enabled" mapstructure:"enabled"`
+}
+
+type CodecConfig struct {
+	DefaultFormat  string               `yaml:"default_format" mapstructure:"default_format"`
+	SchemaRegistry SchemaRegistryConfig `yaml:"schema_registry" mapstructure:"schema_registry"`
+}
+
+type SchemaRegistryConfig struct {
+	URL      string        `yaml:"url" mapstructure:"url"`
+	Username string        `yaml:"username" mapstructure:"username"`
+	Password string        `yaml:"password" mapstructure:"password"`
+	Timeout  time.Duration `yaml:"timeout" mapstructure:"timeout"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" mapstructure:"exporter"`
+	Endpoint    string  `yaml:"endpoint" mapstructure:"endpoint"`
+	FilePath    string  `yaml:"file_path" mapstructure:"file_path"`
+	ServiceName string  `yaml:"service_name" mapstructure:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio" mapstructure:"sample_ratio"`
+	Insecure    bool    `yaml:"insecure" mapstructure:"insecure"`
+}
+
+type RetryConfig struct {
+	BaseBackoff time.Duration `yaml:"base_backoff" mapstructure:"base_backoff"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" mapstructure:"max_backoff"`
+	Jitter      float64       `yaml:"jitter" mapstructure:"jitter"`
+	MaxAttempts int           `yaml:"max_attempts" mapstructure:"max_attempts"`
 }
 
 type LoggerConfig struct {
@@ -83,7 +83,7 @@ func loadConfigFile(vpr *viper.Viper) error {
 }
 
 func bindEnvVariables(vpr *viper.Viper) {
-	envBindings := make(map[string]string, 11)
+	envBindings := make(map[string]string, 24)
 	envBindings["database.host"] = "POSTGRES_HOST"
 	envBindings["database.port"] = "POSTGRES_PORT"
 	envBindings["database.user"] = "POSTGRES_USER"
@@ -94,7 +94,20 @@ func bindEnvVariables(vpr *viper.Viper) {
 	envBindings["database.max_idle_conns"] = "POSTGRES_MIN_CONN"
 	envBindings["server.host"] = "SERVER_HOST"
 	envBindings["server.port"] = "SERVER_PORT"
+	envBindings["server.admin_token"] = "ADMIN_TOKEN"
 	envBindings["kafka.brokers"] = "KAFKA_BROKERS"
+	envBindings["kafka.sasl.mechanism"] = "KAFKA_SASL_MECHANISM"
+	envBindings["kafka.sasl.username"] = "KAFKA_SASL_USERNAME"
+	envBindings["kafka.sasl.password"] = "KAFKA_SASL_PASSWORD"
+	envBindings["codec.schema_registry.url"] = "SCHEMA_REGISTRY_URL"
+	envBindings["codec.schema_registry.username"] = "SCHEMA_REGISTRY_USERNAME"
+	envBindings["codec.schema_registry.password"] = "SCHEMA_REGISTRY_PASSWORD"
+	envBindings["tracing.exporter"] = "TRACING_EXPORTER"
+	envBindings["tracing.endpoint"] = "TRACING_ENDPOINT"
+	envBindings["cache.backend"] = "CACHE_BACKEND"
+	envBindings["cache.redis.addr"] = "REDIS_ADDR"
+	envBindings["cache.redis.password"] = "REDIS_PASSWORD"
+	envBindings["cache.snapshot.path"] = "CACHE_SNAPSHOT_PATH"
 
 	for configKey, envKey := range envBindings {
 		_ = vpr.BindEnv(configKey, envKey)
@@ -105,6 +118,11 @@ func setDefaults(vpr *viper.Viper) {
 	setDatabaseDefaults(vpr)
 	setServerDefaults(vpr)
 	setKafkaDefaults(vpr)
+	setCacheDefaults(vpr)
+	setRetryDefaults(vpr)
+	setOutboxDefaults(vpr)
+	setCodecDefaults(vpr)
+	setTracingDefaults(vpr)
 	setLoggerDefaults(vpr)
 	setShutdownDefaults(vpr)
 }
@@ -133,12 +151,15 @@ func setDatabaseDefaults(vpr *viper.Viper) {
 
 func setServerDefaults(vpr *viper.Viper) {
 	defaults := map[string]interface{}{
-		"server.host":             "0.0.0.0",
-		"server.port":             8080,
-		"server.timeout":          "5s",
-		"server.idle_timeout":     "60s",
-		"server.read_timeout":     "5s",
-		"server.shutdown_timeout": "10s",
+		"server.host":                            "0.0.0.0",
+		"server.port":                            8080,
+		"server.timeout":                         "5s",
+		"server.idle_timeout":                    "60s",
+		"server.read_timeout":                    "5s",
+		"server.shutdown_timeout":                "10s",
+		"server.ingest.max_batch_size":           1000,
+		"server.ingest.idempotency_ttl":          "24h",
+		"server.ingest.idempotency_lock_timeout": "1m",
 	}
 
 	for key, value := range defaults {
@@ -152,13 +173,112 @@ func setKafkaDefaults(vpr *viper.Viper) {
 		"kafka.topic":              "orders",
 		"kafka.group_id":           "order-service-group",
 		"kafka.auto_offset_reset":  "earliest",
+		"kafka.dead_letter_topic":  "orders.dlq",
+		"kafka.events_topic":       "orders.events",
+		"kafka.workers":            4,
+		"kafka.ordering_key":       "partition",
+		"kafka.batch_size":         1,
+		"kafka.batch_linger":       "100ms",
 		"kafka.session_timeout":    "30s",
 		"kafka.max_wait":           "10s",
 		"kafka.min_bytes":          10240,
 		"kafka.max_bytes":          10485760,
-		"kafka.max_retries":        3,
 		"kafka.enable_auto_commit": false,
+		"kafka.commit_strategy":    "sync",
 		"kafka.commit_interval":    "1s",
+		"kafka.tls.enabled":        false,
+		"kafka.sasl.mechanism":     "",
+	}
+
+	for key, value := range defaults {
+		vpr.SetDefault(key, value)
+	}
+}
+
+func setRetryDefaults(vpr *viper.Viper) {
+	defaults := map[string]interface{}{
+		"retry.max_attempts": 3,
+		"retry.base_backoff": "100ms",
+		"retry.max_backoff":  "5s",
+		"retry.jitter":       0.2,
+	}
+
+	for key, value := range defaults {
+		vpr.SetDefault(key, value)
+	}
+}
+
+func setOutboxDefaults(vpr *viper.Viper) {
+	defaults := map[string]interface{}{
+		"outbox.enabled":       true,
+		"outbox.poll_interval": "1s",
+		"outbox.batch_size":    100,
+		"outbox.retention":     "168h",
+		"outbox.max_attempts":  10,
+		"outbox.base_backoff":  "1s",
+		"outbox.max_backoff":   "5m",
+	}
+
+	for key, value := range defaults {
+		vpr.SetDefault(key, value)
+	}
+}
+
+func setCacheDefaults(vpr *viper.Viper) {
+	defaults := map[string]interface{}{
+		"cache.backend":                    "memory",
+		"cache.policy":                     "lru",
+		"cache.ttl":                        "24h",
+		"cache.negative_ttl":               "5s",
+		"cache.max_entries":                10000,
+		"cache.max_bytes":                  256 << 20,
+		"cache.warmup.strategy":            "recent",
+		"cache.warmup.window":              "24h",
+		"cache.warmup.limit":               1000,
+		"cache.warmup.page_size":           200,
+		"cache.warmup.async":               false,
+		"cache.redis.addr":                 "localhost:6379",
+		"cache.redis.key_prefix":           "order:",
+		"cache.redis.dial_timeout":         "5s",
+		"cache.redis.timeout":              "1s",
+		"cache.redis.local_ttl":            "30s",
+		"cache.redis.db":                   0,
+		"cache.redis.pool_size":            10,
+		"cache.redis.tls":                  false,
+		"cache.invalidation.enabled":       true,
+		"cache.invalidation.ping_interval": "30s",
+		"cache.invalidation.base_backoff":  "1s",
+		"cache.invalidation.max_backoff":   "30s",
+		"cache.snapshot.path":              "",
+		"cache.snapshot.interval":          "5m",
+		"cache.snapshot.max_age":           "24h",
+	}
+
+	for key, value := range defaults {
+		vpr.SetDefault(key, value)
+	}
+}
+
+func setCodecDefaults(vpr *viper.Viper) {
+	defaults := map[string]interface{}{
+		"codec.default_format":          "json",
+		"codec.schema_registry.url":     "",
+		"codec.schema_registry.timeout": "5s",
+	}
+
+	for key, value := range defaults {
+		vpr.SetDefault(key, value)
+	}
+}
+
+func setTracingDefaults(vpr *viper.Viper) {
+	defaults := map[string]interface{}{
+		"tracing.exporter":     "none",
+		"tracing.endpoint":     "localhost:4318",
+		"tracing.file_path":    "traces.jsonl",
+		"tracing.service_name": "order-service",
+		"tracing.sample_ratio": 1.0,
+		"tracing.insecure":     true,
 	}
 
 	for key, value := range defaults {
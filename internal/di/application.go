@@ -4,22 +4,33 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
-	"sync"
+	"slices"
 	"syscall"
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/cache"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/codec"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/db/postgres"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/db/postgres/connect"
 	consumer "github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/kafka"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/server"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/adapters/server/handlers"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/app/idempotency"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/app/invalidation"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/app/order"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/app/outbox"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/app/snapshot"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/health"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/logger"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/metrics"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/ports"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/retry"
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/shutdown"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
 )
 
 const AppVersion = "1.0.0"
@@ -42,99 +53,180 @@ func RunService() error {
 	ctx, cancel := context.WithCancelCause(context.Background())
 	defer cancel(nil)
 
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, AppVersion, zapLogger)
+	if err != nil {
+		zapLogger.Error("failed to initialize tracing", "error", err)
+		return fmt.Errorf("%w: tracing: %w", ErrApplicationStartup, err)
+	}
+
 	components, err := initComponents(ctx, cfg, zapLogger)
 	if err != nil {
 		zapLogger.Error("failed to initialize components", "error", err)
 		return fmt.Errorf("%w: %w", ErrApplicationStartup, err)
 	}
+	components.shutdownTracing = shutdownTracing
 
-	startServices(ctx, components, zapLogger)
+	if err := startServices(ctx, components, zapLogger); err != nil {
+		zapLogger.Error("failed to start services", "error", err)
+		components.gracefulShutdown(context.WithoutCancel(ctx), components.shutdownHooks(zapLogger)...)
+		return fmt.Errorf("%w: %w", ErrApplicationStartup, err)
+	}
 
 	zapLogger.Info("application is running, press Ctrl+C to stop")
 
-	return handleShutdown(ctx, cancel, cfg, components, zapLogger)
+	return handleShutdown(ctx, cancel, components, zapLogger)
 }
 
-func initComponents(ctx context.Context, cfg *config.Config, log logger.Logger) (*serviceComponents, error) {
+func initComponents(ctx context.Context, cfg *config.Config, log logger.Logger) (_ *serviceComponents, err error) {
+	if err := consumer.ValidateSecurity(cfg.Kafka); err != nil {
+		return nil, fmt.Errorf("kafka config: %w", err)
+	}
+
+	decoder, err := NewDecoder(cfg.Codec, log)
+	if err != nil {
+		return nil, fmt.Errorf("codec: %w", err)
+	}
+
 	database, err := NewDatabase(ctx, cfg.Database, log)
 	if err != nil {
 		return nil, fmt.Errorf("database: %w", err)
 	}
 	log.Info("database initialized successfully")
 
-	repo := NewRepository(database, log)
-	caches := NewCache(log)
+	// On failure, release what was already set up, newest first.
+	var cleanups []func()
+	defer func() {
+		if err != nil {
+			for _, cleanup := range slices.Backward(cleanups) {
+				cleanup()
+			}
+		}
+	}()
+	cleanups = append(cleanups, database.Close)
 
-	if err := caches.RestoreFromDB(ctx, repo); err != nil {
-		log.Warn("failed to restore caches from DB", "error", err)
-	} else {
-		log.Info("the cache has been fully restored")
+	repo := NewRepository(database, cfg.Kafka, cfg.Outbox, log)
+	caches, err := NewCache(cfg.Cache, log)
+	if err != nil {
+		return nil, fmt.Errorf("cache: %w", err)
+	}
+	if closer, ok := caches.(io.Closer); ok {
+		cleanups = append(cleanups, func() { _ = closer.Close() })
+	}
+	invalidationListener := NewInvalidationListener(database, caches, repo, cfg.Cache.Invalidation, log)
+	if invalidationListener != nil {
+		if err := invalidationListener.Start(ctx); err != nil {
+			return nil, fmt.Errorf("cache invalidation listener: %w", err)
+		}
+		cleanups = append(cleanups, func() { _ = invalidationListener.Stop(context.WithoutCancel(ctx)) })
 	}
+	cacheRestore := WarmUpCache(ctx, cfg.Cache, caches, repo, log)
+	snapshotWriter := NewSnapshotWriter(cfg.Cache.Snapshot, caches, log)
 
-	service := NewService(repo, caches, log)
-	kafkaConsumer := NewKafkaConsumer(cfg.Kafka, service, log)
-	httpServer := NewHTTPServer(caches, repo, log, cfg.Server)
+	service := NewService(repo, caches, decoder, cfg.Retry, log)
+	kafkaConsumer, err := NewKafkaConsumer(cfg.Kafka, cfg.Retry, service, log)
+	if err != nil {
+		return nil, fmt.Errorf("kafka consumer: %w", err)
+	}
+
+	replayer, err := NewReplayer(cfg.Kafka, service, log)
+	if err != nil {
+		return nil, fmt.Errorf("replayer: %w", err)
+	}
+
+	outboxRelay, err := NewOutboxRelay(database, cfg.Kafka, cfg.Outbox, log)
+	if err != nil {
+		return nil, fmt.Errorf("outbox relay: %w", err)
+	}
+
+	if err := RegisterMetrics(database, caches, kafkaConsumer); err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+
+	healthRegistry := NewHealthRegistry(cfg.Database, database, kafkaConsumer, caches, cacheRestore)
+	ingestGuard := NewIdempotencyGuard(database, cfg.Server.Ingest, log)
+	httpServer := NewHTTPServer(ctx, caches, repo, service, ingestGuard, replayer, healthRegistry, log, cfg.Server, cfg.Cache)
 	gracefulShutdown := NewGracefulShutdown(cfg.Shutdown, log)
 
 	return &serviceComponents{
-		database:         database,
-		repo:             repo,
-		cache:            caches,
-		service:          service,
-		kafkaConsumer:    kafkaConsumer,
-		httpServer:       httpServer,
-		gracefulShutdown: gracefulShutdown,
+		database:             database,
+		repo:                 repo,
+		cache:                caches,
+		service:              service,
+		kafkaConsumer:        kafkaConsumer,
+		outboxRelay:          outboxRelay,
+		invalidationListener: invalidationListener,
+		snapshotWriter:       snapshotWriter,
+		httpServer:           httpServer,
+		gracefulShutdown:     gracefulShutdown,
 	}, nil
 }
 
 type serviceComponents struct {
-	database         *connect.DB
-	repo             ports.OrderRepository
-	cache            ports.Cache
-	service          *order.OrderService
-	kafkaConsumer    ports.KafkaConsumer
-	httpServer       ports.HTTPServer
-	gracefulShutdown func(context.Context, ...func(context.Context) error)
-}
-
-func startServices(ctx context.Context, comp *serviceComponents, log logger.Logger) {
-	var waitGroup sync.WaitGroup
-	waitGroup.Add(2)
-
-	waitGroup.Go(func() {
-		if err := comp.kafkaConsumer.Start(ctx); err != nil {
-			log.Error("failed to start Kafka consumer", "error", err)
+	database             *connect.DB
+	repo                 ports.OrderRepository
+	cache                ports.Cache
+	service              *order.OrderService
+	kafkaConsumer        ports.KafkaConsumer
+	outboxRelay          *outbox.Relay
+	invalidationListener *invalidation.Listener
+	snapshotWriter       *snapshot.Writer
+	httpServer           ports.HTTPServer
+	gracefulShutdown     func(context.Context, ...func(context.Context) error)
+	shutdownTracing      tracing.ShutdownFunc
+}
+
+func startServices(ctx context.Context, comp *serviceComponents, log logger.Logger) error {
+	if err := comp.kafkaConsumer.Start(ctx); err != nil {
+		return fmt.Errorf("start kafka consumer: %w", err)
+	}
+
+	if comp.outboxRelay != nil {
+		if err := comp.outboxRelay.Start(ctx); err != nil {
+			return fmt.Errorf("start outbox relay: %w", err)
 		}
-	})
+	}
 
-	waitGroup.Go(func() {
-		if err := comp.httpServer.Start(ctx); err != nil {
-			log.Error("failed to start HTTP server", "error", err)
+	if comp.snapshotWriter != nil {
+		if err := comp.snapshotWriter.Start(ctx); err != nil {
+			return fmt.Errorf("start cache snapshot writer: %w", err)
 		}
-	})
+	}
+
+	if err := comp.httpServer.Start(ctx); err != nil {
+		return fmt.Errorf("start HTTP server: %w", err)
+	}
 
-	waitGroup.Wait()
+	log.Info("all services started")
+	return nil
 }
 
-func handleShutdown(ctx context.Context, cancel context.CancelCauseFunc, cfg *config.Config, comp *serviceComponents, log logger.Logger) error {
-	sigCh := make(chan os.Signal, 1)
+func handleShutdown(ctx context.Context, cancel context.CancelCauseFunc, comp *serviceComponents, log logger.Logger) error {
+	sigCh := make(chan os.Signal, 2)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	defer signal.Stop(sigCh)
 
-	<-sigCh
-	log.Info("received shutdown signal, starting graceful shutdown")
-
-	cancel(ErrForcedShutdown)
-
-	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, cfg.Shutdown.Timeout)
-	defer shutdownCancel()
+	sig := <-sigCh
+	log.Info("received shutdown signal, starting graceful shutdown", "signal", sig)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		comp.gracefulShutdown(context.WithoutCancel(ctx), comp.shutdownHooks(log)...)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case sig := <-sigCh:
+		log.Warn("received second signal, forcing shutdown", "signal", sig)
+		cancel(ErrForcedShutdown)
+		return ErrForcedShutdown
+	}
+}
 
-	comp.gracefulShutdown(shutdownCtx,
-		func(hookCtx context.Context) error {
-			log.Info("closing database connection")
-			comp.database.Close()
-			return nil
-		},
+// shutdownHooks stops whatever accepts work before the database it writes to.
+func (comp *serviceComponents) shutdownHooks(log logger.Logger) []func(context.Context) error {
+	return []func(context.Context) error{
 		func(hookCtx context.Context) error {
 			log.Info("stopping HTTP server")
 			return comp.httpServer.Stop(hookCtx)
@@ -143,10 +235,45 @@ func handleShutdown(ctx context.Context, cancel context.CancelCauseFunc, cfg *co
 			log.Info("stopping Kafka consumer")
 			return comp.kafkaConsumer.Stop(hookCtx)
 		},
-	)
-
-	log.Info("shutdown completed successfully")
-	return nil
+		func(hookCtx context.Context) error {
+			if comp.outboxRelay == nil {
+				return nil
+			}
+			log.Info("stopping outbox relay")
+			return comp.outboxRelay.Stop(hookCtx)
+		},
+		func(hookCtx context.Context) error {
+			if comp.invalidationListener == nil {
+				return nil
+			}
+			log.Info("stopping cache invalidation listener")
+			return comp.invalidationListener.Stop(hookCtx)
+		},
+		func(hookCtx context.Context) error {
+			if comp.snapshotWriter == nil {
+				return nil
+			}
+			log.Info("writing final cache snapshot")
+			return comp.snapshotWriter.Stop(hookCtx)
+		},
+		func(hookCtx context.Context) error {
+			closer, ok := comp.cache.(io.Closer)
+			if !ok {
+				return nil
+			}
+			log.Info("closing cache client")
+			return closer.Close()
+		},
+		func(hookCtx context.Context) error {
+			log.Info("closing database connection")
+			comp.database.Close()
+			return nil
+		},
+		func(hookCtx context.Context) error {
+			log.Info("flushing traces")
+			return comp.shutdownTracing(hookCtx)
+		},
+	}
 }
 
 func NewZapLogger(cfg config.LoggerConfig) logger.Logger {
@@ -161,32 +288,188 @@ func NewDatabase(ctx context.Context, cfg config.DatabaseConfig, log logger.Logg
 	return db, nil
 }
 
-func NewRepository(db *connect.DB, log logger.Logger) ports.OrderRepository {
-	return postgres.NewOrderRepository(db, log)
+func NewRepository(db *connect.DB, kafkaCfg config.KafkaConfig, outboxCfg config.OutboxConfig, log logger.Logger) ports.OrderRepository {
+	return postgres.NewOrderRepository(db, outboxEnabled(kafkaCfg, outboxCfg), log)
+}
+
+func NewCache(cfg config.CacheConfig, log logger.Logger) (ports.Cache, error) {
+	caches, err := cache.NewCache(cfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("new cache: %w", err)
+	}
+	log.Info("cache initialized", "backend", cfg.Backend, "policy", cfg.Policy)
+	return caches, nil
+}
+
+func NewInvalidationListener(db *connect.DB, caches ports.Cache, repo ports.OrderRepository, cfg config.CacheInvalidationConfig, log logger.Logger) *invalidation.Listener {
+	if !cfg.Enabled {
+		log.Warn("cache invalidation disabled, other replicas' updates are only picked up when entries expire")
+		return nil
+	}
+	return invalidation.NewListener(postgres.NewOrderChangeFeed(db, cfg.PingInterval, log), caches, repo, cfg, log)
+}
+
+// WarmUpCache prefers a usable snapshot to the database and runs in the
+// background when cache.warmup.async is set.
+func WarmUpCache(ctx context.Context, cfg config.CacheConfig, caches ports.Cache, repo ports.OrderRepository, log logger.Logger) *health.Gate {
+	gate := health.NewGate(!cfg.Warmup.Async)
+
+	restore := func() {
+		if loadSnapshot(ctx, cfg.Snapshot, caches, repo, log) {
+			gate.Done(nil)
+			return
+		}
+		err := caches.RestoreFromDB(ctx, repo)
+		if err != nil {
+			log.Warn("failed to restore caches from DB", "error", err)
+		} else {
+			log.Info("the cache has been fully restored")
+		}
+		gate.Done(err)
+	}
+
+	if cfg.Warmup.Async {
+		log.Info("warming up the cache in the background", "strategy", cfg.Warmup.Strategy)
+		go restore()
+	} else {
+		restore()
+	}
+	return gate
+}
+
+func loadSnapshot(ctx context.Context, cfg config.CacheSnapshotConfig, caches ports.Cache, repo ports.OrderRepository, log logger.Logger) bool {
+	if cfg.Path == "" {
+		return false
+	}
+	snapshotter, ok := caches.(ports.CacheSnapshotter)
+	if !ok {
+		return false
+	}
+	if err := snapshotter.LoadSnapshot(ctx, cfg.Path, cfg.MaxAge, repo); err != nil {
+		log.Warn("cache snapshot not used, restoring from DB", "path", cfg.Path, "error", err)
+		return false
+	}
+	return true
+}
+
+// NewSnapshotWriter returns nil when snapshots are disabled or not supported.
+func NewSnapshotWriter(cfg config.CacheSnapshotConfig, caches ports.Cache, log logger.Logger) *snapshot.Writer {
+	if cfg.Path == "" {
+		return nil
+	}
+	snapshotter, ok := caches.(ports.CacheSnapshotter)
+	if !ok {
+		log.Warn("cache backend does not support snapshots, cache.snapshot.path ignored")
+		return nil
+	}
+	return snapshot.NewWriter(snapshotter, cfg, log)
+}
+
+func NewDecoder(cfg config.CodecConfig, log logger.Logger) (ports.OrderDecoder, error) {
+	decoder, err := codec.NewDecoder(cfg, codec.NewSchemaRegistry(cfg.SchemaRegistry), log)
+	if err != nil {
+		return nil, fmt.Errorf("new decoder: %w", err)
+	}
+	return decoder, nil
+}
+
+func NewService(repo ports.OrderRepository, cache ports.Cache, decoder ports.OrderDecoder, retryCfg config.RetryConfig, log logger.Logger) *order.OrderService {
+	savePolicy := retry.NewPolicy(retryCfg, postgres.IsRetryableError)
+	return order.NewOrderService(repo, cache, decoder, savePolicy, log)
+}
+
+func NewKafkaConsumer(cfg config.KafkaConfig, retryCfg config.RetryConfig, service *order.OrderService, log logger.Logger) (ports.KafkaConsumer, error) {
+	handlers := map[string]ports.MessageHandler{
+		consumer.HandlerOrders: service,
+	}
+
+	kafkaConsumer, err := consumer.NewKafkaConsumerWithConfig(cfg, retryCfg, handlers, log)
+	if err != nil {
+		return nil, fmt.Errorf("new kafka consumer: %w", err)
+	}
+	return kafkaConsumer, nil
+}
+
+func NewReplayer(cfg config.KafkaConfig, service ports.OrderService, log logger.Logger) (ports.Replayer, error) {
+	replayer, err := consumer.NewReplayer(cfg, service, log)
+	if err != nil {
+		return nil, fmt.Errorf("new replayer: %w", err)
+	}
+	return replayer, nil
+}
+
+func NewOutboxRelay(db *connect.DB, kafkaCfg config.KafkaConfig, cfg config.OutboxConfig, log logger.Logger) (*outbox.Relay, error) {
+	if !outboxEnabled(kafkaCfg, cfg) {
+		log.Warn("outbox relay disabled, order events are not recorded")
+		return nil, nil
+	}
+
+	publisher, err := consumer.NewEventPublisher(kafkaCfg, log)
+	if err != nil {
+		return nil, fmt.Errorf("new event publisher: %w", err)
+	}
+	return outbox.NewRelay(postgres.NewOutboxStore(db, cfg, log), publisher, cfg, log), nil
 }
 
-func NewCache(log logger.Logger) ports.Cache {
-	return cache.NewInMemoryCache(log)
+func outboxEnabled(kafkaCfg config.KafkaConfig, cfg config.OutboxConfig) bool {
+	return cfg.Enabled && kafkaCfg.EventsTopic != ""
 }
 
-func NewService(repo ports.OrderRepository, cache ports.Cache, log logger.Logger) *order.OrderService {
-	return order.NewOrderService(repo, cache, log)
+func RegisterMetrics(db *connect.DB, cache ports.Cache, kafkaConsumer ports.KafkaConsumer) error {
+	if err := metrics.RegisterPool(db.Stats); err != nil {
+		return fmt.Errorf("register pool metrics: %w", err)
+	}
+	if err := metrics.RegisterCache(cache.Stats); err != nil {
+		return fmt.Errorf("register cache metrics: %w", err)
+	}
+	if err := metrics.RegisterConsumer(kafkaConsumer.ReaderStats, kafkaConsumer.Stats); err != nil {
+		return fmt.Errorf("register consumer metrics: %w", err)
+	}
+	return nil
+}
+
+func NewHealthRegistry(dbCfg config.DatabaseConfig, db *connect.DB, kafkaConsumer ports.KafkaConsumer, caches ports.Cache, cacheRestore *health.Gate) *health.Registry {
+	registry := health.NewRegistry()
+	registry.AddReadiness("database", health.DefaultTimeout, db.HealthCheck)
+	registry.AddReadiness("migrations", health.DefaultTimeout, db.MigrationCheck(dbCfg.MigrationsPath))
+	registry.AddReadiness("kafka", health.DefaultTimeout, kafkaConsumer.HealthCheck)
+	registry.AddReadiness("cache_restore", health.DefaultTimeout, cacheRestore.Check)
+	if checker, ok := caches.(interface {
+		HealthCheck(ctx context.Context) (map[string]any, error)
+	}); ok {
+		registry.AddReadiness("cache", health.DefaultTimeout, checker.HealthCheck)
+	}
+	return registry
 }
 
-func NewKafkaConsumer(cfg config.KafkaConfig, service ports.OrderService, log logger.Logger) ports.KafkaConsumer {
-	return consumer.NewKafkaConsumerWithConfig(cfg, service, log)
+func NewIdempotencyGuard(db *connect.DB, cfg config.IngestConfig, log logger.Logger) ports.IdempotencyGuard {
+	return idempotency.NewGuard(postgres.NewIdempotencyStore(db, log), cfg, log)
 }
 
-func NewHTTPServer(cache ports.Cache, repo ports.OrderRepository, log logger.Logger, cfg config.ServerConfig) ports.HTTPServer {
+func NewHTTPServer(ctx context.Context, cache ports.Cache, repo ports.OrderRepository, service ports.OrderService, ingestGuard ports.IdempotencyGuard, replayer ports.Replayer, healthRegistry *health.Registry, log logger.Logger, cfg config.ServerConfig, cacheCfg config.CacheConfig) ports.HTTPServer {
 	httpSrv := server.NewHTTPServer(log, cfg)
-	orderHandler := handlers.OrderHandler(cache, repo, log)
+	orderHandler := handlers.OrderHandler(cache, repo, cacheCfg.NegativeTTL, log)
 	httpSrv.RegisterRoutes(orderHandler)
+	httpSrv.RegisterLookupRoutes(
+		handlers.OrderLookupHandler(domain.IndexTrackNumber, cache, repo, log),
+		handlers.OrderLookupHandler(domain.IndexCustomerID, cache, repo, log),
+		handlers.OrderLookupHandler(domain.IndexItemRID, cache, repo, log),
+		handlers.OrderLookupHandler(domain.IndexItemNmID, cache, repo, log),
+	)
+	httpSrv.RegisterSearchRoutes(handlers.OrderSearchHandler(repo, log))
+
+	ingest := handlers.NewOrderIngest(service, ingestGuard, cfg.Ingest.MaxBatchSize, log)
+	httpSrv.RegisterIngestRoutes(ingest.SingleHandler(), ingest.BatchHandler())
+	httpSrv.RegisterHealthRoutes(handlers.LivenessHandler(healthRegistry), handlers.ReadinessHandler(healthRegistry, log))
+
+	replayJobs := handlers.NewReplayJobs(ctx, replayer, log)
+	httpSrv.RegisterAdminRoutes(replayJobs.StartHandler(), replayJobs.StatusHandler())
 	return httpSrv
 }
 
 func NewGracefulShutdown(cfg config.ShutdownConfig, log logger.Logger) func(context.Context, ...func(context.Context) error) {
 	timeout := cfg.Timeout
 	return func(ctx context.Context, hooks ...func(context.Context) error) {
-		shutdown.Wait(ctx, timeout, log, hooks...)
+		shutdown.Run(ctx, timeout, log, hooks...)
 	}
 }
@@ -0,0 +1,192 @@
+package di
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/tracing"
+)
+
+var ErrInvalidReplayArgs = errors.New("invalid replay arguments")
+
+func RunReplay(args []string) error {
+	req, err := parseReplayArgs(args)
+	if err != nil {
+		return err
+	}
+
+	cfg := config.MustLoad()
+	zapLogger := NewZapLogger(cfg.Logger)
+
+	decoder, err := NewDecoder(cfg.Codec, zapLogger)
+	if err != nil {
+		return fmt.Errorf("codec: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing, AppVersion, zapLogger)
+	if err != nil {
+		return fmt.Errorf("tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.WithoutCancel(ctx)); err != nil {
+			zapLogger.Warn("failed to flush traces", "error", err)
+		}
+	}()
+
+	database, err := NewDatabase(ctx, cfg.Database, zapLogger)
+	if err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+	defer database.Close()
+
+	repo := NewRepository(database, cfg.Kafka, cfg.Outbox, zapLogger)
+	caches, err := NewCache(cfg.Cache, zapLogger)
+	if err != nil {
+		return fmt.Errorf("cache: %w", err)
+	}
+	if closer, ok := caches.(io.Closer); ok {
+		defer func() { _ = closer.Close() }()
+	}
+
+	service := NewService(repo, caches, decoder, cfg.Retry, zapLogger)
+	replayer, err := NewReplayer(cfg.Kafka, service, zapLogger)
+	if err != nil {
+		return err
+	}
+
+	report, replayErr := replayer.Replay(ctx, req)
+	if report != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("write replay report: %w", err)
+		}
+	}
+
+	if replayErr != nil {
+		return fmt.Errorf("replay: %w", replayErr)
+	}
+	return nil
+}
+
+func parseReplayArgs(args []string) (domain.ReplayRequest, error) {
+	var (
+		req        domain.ReplayRequest
+		from       string
+		to         string
+		offsets    string
+		partitions string
+	)
+
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	flags.StringVar(&req.Topic, "topic", "", "topic to replay, one of kafka.topics (default: the only consumed topic)")
+	flags.StringVar(&from, "from", "", "replay messages produced at or after this RFC3339 timestamp")
+	flags.StringVar(&to, "to", "", "stop at messages produced after this RFC3339 timestamp")
+	flags.StringVar(&offsets, "offsets", "", "explicit offset ranges, e.g. 0:100-200,1:50- (end is inclusive and optional)")
+	flags.StringVar(&partitions, "partitions", "", "comma-separated partitions to replay (default: all)")
+	flags.BoolVar(&req.DryRun, "dry-run", false, "only report what would be saved or rejected")
+
+	if err := flags.Parse(args); err != nil {
+		return req, fmt.Errorf("%w: %w", ErrInvalidReplayArgs, err)
+	}
+
+	var err error
+	if req.From, err = parseReplayTime(from); err != nil {
+		return req, err
+	}
+	if req.To, err = parseReplayTime(to); err != nil {
+		return req, err
+	}
+	if req.Offsets, err = parseOffsetRanges(offsets); err != nil {
+		return req, err
+	}
+	if req.Partitions, err = parsePartitions(partitions); err != nil {
+		return req, err
+	}
+
+	if req.From.IsZero() && len(req.Offsets) == 0 {
+		return req, fmt.Errorf("%w: either -from or -offsets is required", ErrInvalidReplayArgs)
+	}
+
+	return req, nil
+}
+
+func parseReplayTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: timestamp %q: %w", ErrInvalidReplayArgs, value, err)
+	}
+	return parsed, nil
+}
+
+func parseOffsetRanges(value string) (map[int]domain.OffsetRange, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	ranges := make(map[int]domain.OffsetRange)
+	for _, spec := range strings.Split(value, ",") {
+		partitionPart, rangePart, found := strings.Cut(strings.TrimSpace(spec), ":")
+		if !found {
+			return nil, fmt.Errorf("%w: offset range %q must look like partition:start-end", ErrInvalidReplayArgs, spec)
+		}
+
+		partition, err := strconv.Atoi(partitionPart)
+		if err != nil {
+			return nil, fmt.Errorf("%w: partition %q: %w", ErrInvalidReplayArgs, partitionPart, err)
+		}
+
+		startPart, endPart, _ := strings.Cut(rangePart, "-")
+		start, err := strconv.ParseInt(startPart, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: start offset %q: %w", ErrInvalidReplayArgs, startPart, err)
+		}
+
+		var end int64
+		if endPart != "" {
+			if end, err = strconv.ParseInt(endPart, 10, 64); err != nil {
+				return nil, fmt.Errorf("%w: end offset %q: %w", ErrInvalidReplayArgs, endPart, err)
+			}
+		}
+
+		ranges[partition] = domain.OffsetRange{Start: start, End: end}
+	}
+
+	return ranges, nil
+}
+
+func parsePartitions(value string) ([]int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(value, ",")
+	partitions := make([]int, 0, len(parts))
+	for _, part := range parts {
+		partition, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("%w: partition %q: %w", ErrInvalidReplayArgs, part, err)
+		}
+		partitions = append(partitions, partition)
+	}
+	return partitions, nil
+}
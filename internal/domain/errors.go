@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+
+type ProcessingErrorKind string
+
+const (
+	ProcessingErrorRejected ProcessingErrorKind = "rejected"
+	ProcessingErrorFailed   ProcessingErrorKind = "failed"
+)
+
+type FailureReason string
+
+const (
+	ReasonEmptyPayload      FailureReason = "empty_payload"
+	ReasonInvalidJSON       FailureReason = "invalid_json"
+	ReasonEmptyOrderUID     FailureReason = "empty_order_uid"
+	ReasonValidationFailed  FailureReason = "validation_failed"
+	ReasonSaveFailed        FailureReason = "save_failed"
+	ReasonSaveRejected      FailureReason = "save_rejected"
+	ReasonRetriesExhausted  FailureReason = "retries_exhausted"
+	ReasonNoHandler         FailureReason = "no_handler"
+	ReasonInvalidPayload    FailureReason = "invalid_payload"
+	ReasonUnsupportedFormat FailureReason = "unsupported_format"
+	ReasonUnknownSchema     FailureReason = "unknown_schema"
+	ReasonSchemaUnavailable FailureReason = "schema_unavailable"
+	ReasonUnexpectedError   FailureReason = "unexpected_error"
+)
+
+type ProcessingError struct {
+	Err    error
+	Kind   ProcessingErrorKind
+	Reason FailureReason
+}
+
+func NewRejectedError(reason FailureReason, err error) *ProcessingError {
+	return &ProcessingError{Err: err, Kind: ProcessingErrorRejected, Reason: reason}
+}
+
+func NewFailedError(reason FailureReason, err error) *ProcessingError {
+	return &ProcessingError{Err: err, Kind: ProcessingErrorFailed, Reason: reason}
+}
+
+func (e *ProcessingError) Error() string {
+	return fmt.Sprintf("%s (%s): %v", e.Kind, e.Reason, e.Err)
+}
+
+func (e *ProcessingError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ProcessingError) Rejected() bool {
+	return e.Kind == ProcessingErrorRejected
+}
+
+func AsProcessingError(err error) (*ProcessingError, bool) {
+	var procErr *ProcessingError
+	if errors.As(err, &procErr) {
+		return procErr, true
+	}
+	return nil, false
+}
+
+// FieldError points a validation error at a field such as "items[2].price".
+type FieldError struct {
+	Err   error
+	Field string
+}
+
+func NewFieldError(field string, err error) *FieldError {
+	return &FieldError{Err: err, Field: field}
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,66 @@
+package domain
+
+import "time"
+
+const EventOrderPersisted = "order.persisted"
+
+type OrderPersistedEvent struct {
+	PersistedAt  time.Time `json:"persisted_at"`
+	OrderUID     string    `json:"order_uid"`
+	TrackNumber  string    `json:"track_number"`
+	CustomerID   string    `json:"customer_id"`
+	Currency     string    `json:"currency,omitempty"`
+	Amount       float64   `json:"amount"`
+	GoodsTotal   float64   `json:"goods_total"`
+	DeliveryCost float64   `json:"delivery_cost"`
+	Version      int64     `json:"version"`
+	ItemsCount   int       `json:"items_count"`
+}
+
+func NewOrderPersistedEvent(order *Order) OrderPersistedEvent {
+	event := OrderPersistedEvent{
+		PersistedAt: order.UpdatedAt,
+		OrderUID:    order.OrderUID,
+		TrackNumber: order.TrackNumber,
+		CustomerID:  order.CustomerID,
+		Version:     order.Version,
+		ItemsCount:  len(order.Items),
+	}
+	if order.Payment != nil {
+		event.Currency = order.Payment.Currency
+		event.Amount = order.Payment.Amount
+		event.GoodsTotal = order.Payment.GoodsTotal
+		event.DeliveryCost = order.Payment.DeliveryCost
+	}
+	return event
+}
+
+// OrderChange is broadcast to every replica after an order was written. Keys is
+// nil when they are not known.
+type OrderChange struct {
+	Keys     map[OrderIndex][]string `json:"keys"`
+	OrderUID string                  `json:"order_uid"`
+	Version  int64                   `json:"version"`
+}
+
+func NewOrderChange(order *Order) OrderChange {
+	keys := make(map[OrderIndex][]string, len(OrderIndexes))
+	for _, index := range OrderIndexes {
+		if index.Unique() {
+			continue
+		}
+		if indexKeys := order.IndexKeys(index); len(indexKeys) > 0 {
+			keys[index] = indexKeys
+		}
+	}
+	return OrderChange{Keys: keys, OrderUID: order.OrderUID, Version: order.Version}
+}
+
+type OutboxEvent struct {
+	CreatedAt   time.Time
+	AggregateID string
+	EventType   string
+	Payload     []byte
+	ID          int64
+	Attempts    int
+}
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+	ErrRequestInProgress    = errors.New("request with this idempotency key is still in progress")
+	ErrIdempotencyClaimLost = errors.New("idempotency key was taken over by another request")
+)
+
+type IdempotentResponse struct {
+	Body       []byte
+	StatusCode int
+}
+
+// IdempotencyRecord is an existing claim; Response is nil until it completes.
+type IdempotencyRecord struct {
+	CreatedAt   time.Time
+	Response    *IdempotentResponse
+	RequestHash string
+}
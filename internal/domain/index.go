@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+var (
+	ErrUnknownOrderIndex = errors.New("unknown order index")
+	ErrInvalidIndexKey   = errors.New("invalid order index key")
+)
+
+// OrderIndex names a secondary key orders can be looked up by besides order_uid.
+type OrderIndex string
+
+const (
+	IndexTrackNumber OrderIndex = "track_number"
+	IndexCustomerID  OrderIndex = "customer_id"
+	IndexItemRID     OrderIndex = "rid"
+	IndexItemNmID    OrderIndex = "nm_id"
+)
+
+var OrderIndexes = []OrderIndex{IndexTrackNumber, IndexCustomerID, IndexItemRID, IndexItemNmID}
+
+// Unique reports whether a key of the index identifies at most one order.
+func (index OrderIndex) Unique() bool {
+	return index == IndexTrackNumber
+}
+
+// ValidateKey rejects keys that cannot match any order in the index.
+func (index OrderIndex) ValidateKey(key string) error {
+	if !slices.Contains(OrderIndexes, index) {
+		return fmt.Errorf("%w: %s", ErrUnknownOrderIndex, index)
+	}
+	if key == "" {
+		return fmt.Errorf("%w: empty %s", ErrInvalidIndexKey, index)
+	}
+	if index == IndexItemNmID {
+		if _, err := strconv.ParseInt(key, 10, 64); err != nil {
+			return fmt.Errorf("%w: %s must be an integer", ErrInvalidIndexKey, index)
+		}
+	}
+	return nil
+}
+
+// IndexKeys returns the distinct non-empty keys of the order in the index.
+func (o *Order) IndexKeys(index OrderIndex) []string {
+	switch index {
+	case IndexTrackNumber:
+		return nonEmpty(o.TrackNumber)
+	case IndexCustomerID:
+		return nonEmpty(o.CustomerID)
+	case IndexItemRID, IndexItemNmID:
+		keys := make([]string, 0, len(o.Items))
+		seen := make(map[string]struct{}, len(o.Items))
+		for _, item := range o.Items {
+			key := item.RID
+			if index == IndexItemNmID {
+				key = ""
+				if item.NmID != 0 {
+					key = strconv.FormatInt(item.NmID, 10)
+				}
+			}
+			if _, dup := seen[key]; dup || key == "" {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+		return keys
+	default:
+		return nil
+	}
+}
+
+func nonEmpty(key string) []string {
+	if key == "" {
+		return nil
+	}
+	return []string{key}
+}
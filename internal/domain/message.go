@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	HeaderContentType = "content-type"
+	HeaderTraceID     = "trace_id"
+	HeaderRequestID   = "x-request-id"
+	HeaderTraceParent = "traceparent"
+)
+
+type Message struct {
+	Time      time.Time
+	Headers   map[string]string
+	Topic     string
+	TraceID   string
+	Key       []byte
+	Value     []byte
+	Offset    int64
+	Partition int
+}
+
+func (m *Message) Header(key string) string {
+	if m == nil || m.Headers == nil {
+		return ""
+	}
+	if value, exists := m.Headers[key]; exists {
+		return value
+	}
+	for name, value := range m.Headers {
+		if strings.EqualFold(name, key) {
+			return value
+		}
+	}
+	return ""
+}
@@ -19,6 +19,7 @@ type Order struct {
 	OofShard          string    `json:"oof_shard" db:"oof_shard"`
 	Delivery          *Delivery `json:"delivery"`
 	Payment           *Payment  `json:"payment"`
+	Version           int64     `json:"-" db:"version"`
 	SmID              int       `json:"sm_id" db:"sm_id"`
 }
 
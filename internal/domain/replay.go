@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+type ReplayAction string
+
+const (
+	ReplaySaved     ReplayAction = "saved"
+	ReplayWouldSave ReplayAction = "would_save"
+	ReplayRejected  ReplayAction = "rejected"
+	ReplayFailed    ReplayAction = "failed"
+)
+
+type OffsetRange struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+type ReplayRequest struct {
+	From       time.Time           `json:"from"`
+	To         time.Time           `json:"to"`
+	Offsets    map[int]OffsetRange `json:"offsets"`
+	Topic      string              `json:"topic"`
+	Partitions []int               `json:"partitions"`
+	DryRun     bool                `json:"dry_run"`
+}
+
+type ReplayOutcome struct {
+	OrderUID  string        `json:"order_uid,omitempty"`
+	Action    ReplayAction  `json:"action"`
+	Reason    FailureReason `json:"reason,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	Offset    int64         `json:"offset"`
+	Partition int           `json:"partition"`
+}
+
+type PartitionReplay struct {
+	Partition   int   `json:"partition"`
+	StartOffset int64 `json:"start_offset"`
+	EndOffset   int64 `json:"end_offset"`
+	Processed   int   `json:"processed"`
+}
+
+type ReplayReport struct {
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Topic      string            `json:"topic"`
+	Partitions []PartitionReplay `json:"partitions"`
+	Outcomes   []ReplayOutcome   `json:"outcomes"`
+	Processed  int               `json:"processed"`
+	Saved      int               `json:"saved"`
+	Rejected   int               `json:"rejected"`
+	Failed     int               `json:"failed"`
+	Truncated  bool              `json:"truncated"`
+	DryRun     bool              `json:"dry_run"`
+}
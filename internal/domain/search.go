@@ -0,0 +1,149 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+var (
+	ErrInvalidOrderSearch = errors.New("invalid order search")
+	ErrInvalidCursor      = errors.New("invalid order cursor")
+)
+
+// OrderSortField names a timestamp orders can be listed by; order_uid breaks ties.
+type OrderSortField string
+
+const (
+	SortByDateCreated OrderSortField = "date_created"
+	SortByCreatedAt   OrderSortField = "created_at"
+)
+
+type OrderSort struct {
+	Field OrderSortField `json:"field"`
+	Desc  bool           `json:"desc"`
+}
+
+// DefaultOrderSort lists the newest orders first, by the indexed date_created.
+var DefaultOrderSort = OrderSort{Field: SortByDateCreated, Desc: true}
+
+// ParseOrderSort reads a field name, prefixed with "-" for descending order.
+func ParseOrderSort(value string) (OrderSort, error) {
+	if value == "" {
+		return DefaultOrderSort, nil
+	}
+
+	field, desc := strings.CutPrefix(value, "-")
+	sort := OrderSort{Field: OrderSortField(field), Desc: desc}
+	if err := sort.validate(); err != nil {
+		return OrderSort{}, err
+	}
+	return sort, nil
+}
+
+func (s OrderSort) validate() error {
+	switch s.Field {
+	case SortByDateCreated, SortByCreatedAt:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown sort field %q", ErrInvalidOrderSearch, s.Field)
+	}
+}
+
+func (s OrderSort) String() string {
+	if s.Desc {
+		return "-" + string(s.Field)
+	}
+	return string(s.Field)
+}
+
+// Value returns the timestamp the order is sorted by.
+func (s OrderSort) Value(order *Order) time.Time {
+	if s.Field == SortByCreatedAt {
+		return order.CreatedAt
+	}
+	return order.DateCreated
+}
+
+// OrderFilter narrows a search; DateFrom is inclusive and DateTo exclusive.
+type OrderFilter struct {
+	DateFrom        time.Time
+	DateTo          time.Time
+	CustomerID      string
+	TrackNumber     string
+	DeliveryService string
+	Locale          string
+	PaymentProvider string
+	PaymentCurrency string
+	ItemBrand       string
+	ItemNmID        int64
+}
+
+// OrderCursor is only valid for the sort it was issued for.
+type OrderCursor struct {
+	Value    time.Time `json:"v"`
+	OrderUID string    `json:"u"`
+	Sort     string    `json:"s"`
+}
+
+func NewOrderCursor(sort OrderSort, order *Order) *OrderCursor {
+	return &OrderCursor{Value: sort.Value(order), OrderUID: order.OrderUID, Sort: sort.String()}
+}
+
+func (c *OrderCursor) Encode() string {
+	payload, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(payload)
+}
+
+func DecodeOrderCursor(token string, sort OrderSort) (*OrderCursor, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	var cursor OrderCursor
+	if err := json.Unmarshal(payload, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	if cursor.OrderUID == "" {
+		return nil, fmt.Errorf("%w: missing order_uid", ErrInvalidCursor)
+	}
+	if cursor.Sort != sort.String() {
+		return nil, fmt.Errorf("%w: issued for sort %q, not %q", ErrInvalidCursor, cursor.Sort, sort)
+	}
+	return &cursor, nil
+}
+
+type OrderSearch struct {
+	After  *OrderCursor
+	Filter OrderFilter
+	Sort   OrderSort
+	Limit  int
+}
+
+// Validate rejects searches that are malformed rather than merely empty.
+func (s OrderSearch) Validate() error {
+	if err := s.Sort.validate(); err != nil {
+		return err
+	}
+	if s.Limit < 1 || s.Limit > MaxSearchLimit {
+		return fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidOrderSearch, MaxSearchLimit)
+	}
+	if !s.Filter.DateFrom.IsZero() && !s.Filter.DateTo.IsZero() && !s.Filter.DateFrom.Before(s.Filter.DateTo) {
+		return fmt.Errorf("%w: date_from must be before date_to", ErrInvalidOrderSearch)
+	}
+	return nil
+}
+
+type OrderPage struct {
+	Next   *OrderCursor
+	Orders []*Order
+}
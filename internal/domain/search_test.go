@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseOrderSort(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    OrderSort
+		wantErr bool
+	}{
+		{value: "", want: DefaultOrderSort},
+		{value: "date_created", want: OrderSort{Field: SortByDateCreated}},
+		{value: "-created_at", want: OrderSort{Field: SortByCreatedAt, Desc: true}},
+		{value: "-updated_at", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseOrderSort(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidOrderSearch) {
+					t.Fatalf("ParseOrderSort(%q) error = %v, want ErrInvalidOrderSearch", tt.value, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("ParseOrderSort(%q) = %+v, %v, want %+v", tt.value, got, err, tt.want)
+			}
+			if got.String() != tt.want.String() {
+				t.Fatalf("String() = %q, want %q", got.String(), tt.want.String())
+			}
+		})
+	}
+}
+
+func TestOrderCursorRoundTrip(t *testing.T) {
+	order := &Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		DateCreated: time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC),
+		CreatedAt:   time.Date(2024, 3, 1, 10, 0, 0, 123456000, time.UTC),
+	}
+
+	for _, sort := range []OrderSort{DefaultOrderSort, {Field: SortByCreatedAt}} {
+		t.Run(sort.String(), func(t *testing.T) {
+			token := NewOrderCursor(sort, order).Encode()
+
+			cursor, err := DecodeOrderCursor(token, sort)
+			if err != nil {
+				t.Fatalf("DecodeOrderCursor() error = %v", err)
+			}
+			if cursor.OrderUID != order.OrderUID || !cursor.Value.Equal(sort.Value(order)) {
+				t.Fatalf("decoded cursor = %+v, want %s at %s", cursor, order.OrderUID, sort.Value(order))
+			}
+		})
+	}
+}
+
+func TestDecodeOrderCursorRejectsInvalidTokens(t *testing.T) {
+	issued := NewOrderCursor(DefaultOrderSort, &Order{OrderUID: "b563feb7b2b84b6test"}).Encode()
+	encode := func(payload string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(payload))
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		sort  OrderSort
+	}{
+		{name: "not base64", token: "%%%", sort: DefaultOrderSort},
+		{name: "not json", token: encode("cursor"), sort: DefaultOrderSort},
+		{name: "missing order_uid", token: encode(`{"v":"2024-01-01T00:00:00Z","s":"-date_created"}`), sort: DefaultOrderSort},
+		{name: "other sort", token: issued, sort: OrderSort{Field: SortByDateCreated}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeOrderCursor(tt.token, tt.sort); !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("DecodeOrderCursor() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
+
+func TestOrderSearchValidate(t *testing.T) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		search  OrderSearch
+		wantErr bool
+	}{
+		{name: "valid", search: OrderSearch{Sort: DefaultOrderSort, Limit: DefaultSearchLimit}},
+		{name: "zero limit", search: OrderSearch{Sort: DefaultOrderSort}, wantErr: true},
+		{name: "limit over max", search: OrderSearch{Sort: DefaultOrderSort, Limit: MaxSearchLimit + 1}, wantErr: true},
+		{name: "unknown sort", search: OrderSearch{Sort: OrderSort{Field: "updated_at"}, Limit: 1}, wantErr: true},
+		{
+			name:    "empty date range",
+			search:  OrderSearch{Sort: DefaultOrderSort, Limit: 1, Filter: OrderFilter{DateFrom: day, DateTo: day}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.search.Validate()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Validate() error = %v, want error: %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidOrderSearch) {
+				t.Fatalf("Validate() error = %v, want ErrInvalidOrderSearch", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package domain
+
+import "time"
+
+type CommitStats struct {
+	LastFailure       time.Time
+	Strategy          string
+	Commits           uint64
+	CommittedMessages uint64
+	Failures          uint64
+}
+
+type CacheStats struct {
+	Hits        uint64
+	Misses      uint64
+	Evictions   uint64
+	Expirations uint64
+	Bytes       int64
+	Size        int
+}
+
+type PoolStats struct {
+	AcquireDuration      time.Duration
+	AcquireCount         int64
+	EmptyAcquireCount    int64
+	CanceledAcquireCount int64
+	AcquiredConns        int32
+	IdleConns            int32
+	TotalConns           int32
+	MaxConns             int32
+}
+
+type ReaderStats struct {
+	Lag        int64
+	Messages   int64
+	Bytes      int64
+	Fetches    int64
+	Errors     int64
+	Timeouts   int64
+	Rebalances int64
+}
@@ -0,0 +1,188 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+
+	DefaultTimeout = 2 * time.Second
+)
+
+var (
+	ErrCheckTimeout = errors.New("health check timed out")
+	ErrNotReady     = errors.New("not ready yet")
+)
+
+type CheckFunc func(ctx context.Context) (map[string]any, error)
+
+type Check struct {
+	Run     CheckFunc
+	Name    string
+	Timeout time.Duration
+}
+
+type CheckResult struct {
+	Details    map[string]any `json:"details,omitempty"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+}
+
+type Report struct {
+	Checks map[string]CheckResult `json:"checks"`
+	Status string                 `json:"status"`
+}
+
+func (r Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+type Registry struct {
+	liveness  []Check
+	readiness []Check
+	mu        sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) AddLiveness(name string, timeout time.Duration, run CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness = append(r.liveness, Check{Run: run, Name: name, Timeout: timeout})
+}
+
+func (r *Registry) AddReadiness(name string, timeout time.Duration, run CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness = append(r.readiness, Check{Run: run, Name: name, Timeout: timeout})
+}
+
+func (r *Registry) Liveness(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.liveness
+	r.mu.RUnlock()
+	return runChecks(ctx, checks)
+}
+
+func (r *Registry) Readiness(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := r.readiness
+	r.mu.RUnlock()
+	return runChecks(ctx, checks)
+}
+
+func runChecks(ctx context.Context, checks []Check) Report {
+	results := make([]CheckResult, len(checks))
+
+	var waitGroup sync.WaitGroup
+	for i, check := range checks {
+		waitGroup.Go(func() {
+			results[i] = runCheck(ctx, check)
+		})
+	}
+	waitGroup.Wait()
+
+	report := Report{Checks: make(map[string]CheckResult, len(checks)), Status: StatusOK}
+	for i, check := range checks {
+		if results[i].Status != StatusOK {
+			report.Status = StatusFail
+		}
+		report.Checks[check.Name] = results[i]
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		details map[string]any
+		err     error
+	}
+
+	// A check that ignores its context must not hold the probe past its timeout.
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- outcome{err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		details, err := check.Run(checkCtx)
+		done <- outcome{details: details, err: err}
+	}()
+
+	var result outcome
+	select {
+	case result = <-done:
+	case <-checkCtx.Done():
+		result.err = fmt.Errorf("%w after %v", ErrCheckTimeout, timeout)
+	}
+
+	checkResult := CheckResult{
+		Details:    result.details,
+		Status:     StatusOK,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if result.err != nil {
+		checkResult.Status = StatusFail
+		checkResult.Error = result.err.Error()
+	}
+	return checkResult
+}
+
+// Gate is a readiness check for one-off startup work such as the cache restore.
+type Gate struct {
+	err      error
+	doneAt   time.Time
+	done     bool
+	blocking bool
+	mu       sync.RWMutex
+}
+
+func NewGate(blocking bool) *Gate {
+	return &Gate{blocking: blocking}
+}
+
+// Done marks the work as finished; err is reported but does not fail readiness.
+func (g *Gate) Done(err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.done = true
+	g.doneAt = time.Now()
+	g.err = err
+}
+
+func (g *Gate) Check(context.Context) (map[string]any, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if !g.done {
+		if !g.blocking {
+			return map[string]any{"state": "in_progress"}, nil
+		}
+		return nil, ErrNotReady
+	}
+
+	details := map[string]any{"completed_at": g.doneAt.Format(time.RFC3339)}
+	if g.err != nil {
+		details["last_error"] = g.err.Error()
+	}
+	return details, nil
+}
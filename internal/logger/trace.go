@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+const (
+	traceParentParts   = 4
+	traceParentIDLen   = 32
+	invalidTraceParent = "00000000000000000000000000000000"
+	maxTraceIDLen      = 128
+)
+
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	if traceID == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, TraceIDKey, traceID)
+}
+
+func TraceIDFromContext(ctx context.Context) (string, bool) {
+	traceID, ok := ctx.Value(TraceIDKey).(string)
+	return traceID, ok && traceID != ""
+}
+
+func IsValidTraceID(traceID string) bool {
+	if traceID == "" || len(traceID) > maxTraceIDLen {
+		return false
+	}
+	for _, r := range traceID {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func NewTraceID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// TraceIDFromTraceParent parses a W3C "00-<trace-id>-<parent-id>-<flags>" header.
+func TraceIDFromTraceParent(traceParent string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) != traceParentParts || len(parts[1]) != traceParentIDLen || parts[1] == invalidTraceParent {
+		return "", false
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", false
+	}
+	return strings.ToLower(parts[1]), true
+}
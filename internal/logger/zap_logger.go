@@ -8,7 +8,9 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
-const TraceIDKey = "trace_id"
+type contextKey string
+
+const TraceIDKey contextKey = "trace_id"
 
 type Logger interface {
 	Debug(msg string, fields ...any)
@@ -68,8 +70,8 @@ func (z *ZapLogger) WithField(key string, value any) Logger {
 }
 
 func (z *ZapLogger) WithContext(ctx context.Context) Logger {
-	if traceID, ok := ctx.Value(TraceIDKey).(string); ok {
-		newLogger := z.Logger.With(zap.String(TraceIDKey, traceID))
+	if traceID, ok := TraceIDFromContext(ctx); ok {
+		newLogger := z.Logger.With(zap.String(string(TraceIDKey), traceID))
 		return &ZapLogger{Logger: newLogger, Sugared: newLogger.Sugar()}
 	}
 	return z
@@ -0,0 +1,119 @@
+package metrics
+
+import (
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type statMetric[T any] struct {
+	desc  *prometheus.Desc
+	value func(T) float64
+	kind  prometheus.ValueType
+}
+
+type statsCollector[T any] struct {
+	source  func() T
+	metrics []statMetric[T]
+}
+
+func (c *statsCollector[T]) Describe(ch chan<- *prometheus.Desc) {
+	for _, metric := range c.metrics {
+		ch <- metric.desc
+	}
+}
+
+func (c *statsCollector[T]) Collect(ch chan<- prometheus.Metric) {
+	stats := c.source()
+	for _, metric := range c.metrics {
+		ch <- prometheus.MustNewConstMetric(metric.desc, metric.kind, metric.value(stats))
+	}
+}
+
+func stat[T any](subsystem, name, help string, kind prometheus.ValueType, value func(T) float64) statMetric[T] {
+	return statMetric[T]{
+		desc:  prometheus.NewDesc(prometheus.BuildFQName(Namespace, subsystem, name), help, nil, nil),
+		value: value,
+		kind:  kind,
+	}
+}
+
+func RegisterCache(source func() domain.CacheStats) error {
+	return Registry.Register(&statsCollector[domain.CacheStats]{
+		source: source,
+		metrics: []statMetric[domain.CacheStats]{
+			stat("cache", "hits_total", "Cache lookups that found the order.", prometheus.CounterValue,
+				func(s domain.CacheStats) float64 { return float64(s.Hits) }),
+			stat("cache", "misses_total", "Cache lookups that did not find the order.", prometheus.CounterValue,
+				func(s domain.CacheStats) float64 { return float64(s.Misses) }),
+			stat("cache", "size", "Orders currently held in the cache.", prometheus.GaugeValue,
+				func(s domain.CacheStats) float64 { return float64(s.Size) }),
+			stat("cache", "evictions_total", "Orders evicted to stay within the cache limits.", prometheus.CounterValue,
+				func(s domain.CacheStats) float64 { return float64(s.Evictions) }),
+			stat("cache", "expirations_total", "Orders dropped after their TTL elapsed.", prometheus.CounterValue,
+				func(s domain.CacheStats) float64 { return float64(s.Expirations) }),
+			stat("cache", "bytes", "Estimated memory held by cached orders.", prometheus.GaugeValue,
+				func(s domain.CacheStats) float64 { return float64(s.Bytes) }),
+		},
+	})
+}
+
+func RegisterPool(source func() domain.PoolStats) error {
+	return Registry.Register(&statsCollector[domain.PoolStats]{
+		source: source,
+		metrics: []statMetric[domain.PoolStats]{
+			stat("db_pool", "acquired_connections", "Connections currently checked out of the pool.", prometheus.GaugeValue,
+				func(s domain.PoolStats) float64 { return float64(s.AcquiredConns) }),
+			stat("db_pool", "idle_connections", "Idle connections in the pool.", prometheus.GaugeValue,
+				func(s domain.PoolStats) float64 { return float64(s.IdleConns) }),
+			stat("db_pool", "total_connections", "Open connections in the pool.", prometheus.GaugeValue,
+				func(s domain.PoolStats) float64 { return float64(s.TotalConns) }),
+			stat("db_pool", "max_connections", "Configured maximum pool size.", prometheus.GaugeValue,
+				func(s domain.PoolStats) float64 { return float64(s.MaxConns) }),
+			stat("db_pool", "acquires_total", "Successful connection acquires.", prometheus.CounterValue,
+				func(s domain.PoolStats) float64 { return float64(s.AcquireCount) }),
+			stat("db_pool", "empty_acquires_total", "Acquires that had to wait for a connection.", prometheus.CounterValue,
+				func(s domain.PoolStats) float64 { return float64(s.EmptyAcquireCount) }),
+			stat("db_pool", "canceled_acquires_total", "Acquires canceled by their context.", prometheus.CounterValue,
+				func(s domain.PoolStats) float64 { return float64(s.CanceledAcquireCount) }),
+			stat("db_pool", "acquire_duration_seconds_total", "Total time spent waiting for connections.", prometheus.CounterValue,
+				func(s domain.PoolStats) float64 { return s.AcquireDuration.Seconds() }),
+		},
+	})
+}
+
+func RegisterConsumer(readerStats func() domain.ReaderStats, commitStats func() domain.CommitStats) error {
+	reader := &statsCollector[domain.ReaderStats]{
+		source: readerStats,
+		metrics: []statMetric[domain.ReaderStats]{
+			stat("kafka", "reader_lag", "Messages between the reader position and the partition end.", prometheus.GaugeValue,
+				func(s domain.ReaderStats) float64 { return float64(s.Lag) }),
+			stat("kafka", "reader_messages_total", "Messages read by the Kafka reader.", prometheus.CounterValue,
+				func(s domain.ReaderStats) float64 { return float64(s.Messages) }),
+			stat("kafka", "reader_bytes_total", "Bytes read by the Kafka reader.", prometheus.CounterValue,
+				func(s domain.ReaderStats) float64 { return float64(s.Bytes) }),
+			stat("kafka", "reader_fetches_total", "Fetch requests sent by the Kafka reader.", prometheus.CounterValue,
+				func(s domain.ReaderStats) float64 { return float64(s.Fetches) }),
+			stat("kafka", "reader_errors_total", "Errors reported by the Kafka reader.", prometheus.CounterValue,
+				func(s domain.ReaderStats) float64 { return float64(s.Errors) }),
+			stat("kafka", "reader_timeouts_total", "Fetch timeouts reported by the Kafka reader.", prometheus.CounterValue,
+				func(s domain.ReaderStats) float64 { return float64(s.Timeouts) }),
+			stat("kafka", "reader_rebalances_total", "Consumer group rebalances.", prometheus.CounterValue,
+				func(s domain.ReaderStats) float64 { return float64(s.Rebalances) }),
+		},
+	}
+	if err := Registry.Register(reader); err != nil {
+		return err
+	}
+
+	return Registry.Register(&statsCollector[domain.CommitStats]{
+		source: commitStats,
+		metrics: []statMetric[domain.CommitStats]{
+			stat("kafka", "commits_total", "Offset commit requests sent to Kafka.", prometheus.CounterValue,
+				func(s domain.CommitStats) float64 { return float64(s.Commits) }),
+			stat("kafka", "messages_committed_total", "Messages whose offsets were committed.", prometheus.CounterValue,
+				func(s domain.CommitStats) float64 { return float64(s.CommittedMessages) }),
+			stat("kafka", "commit_failures_total", "Failed offset commit requests.", prometheus.CounterValue,
+				func(s domain.CommitStats) float64 { return float64(s.Failures) }),
+		},
+	})
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	Namespace = "order_service"
+
+	StatusOK       = "ok"
+	StatusError    = "error"
+	StatusRejected = "rejected"
+	StatusFailed   = "failed"
+)
+
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	MessagesConsumed = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "kafka",
+		Name:      "messages_consumed_total",
+		Help:      "Messages fetched from Kafka.",
+	}, []string{"topic"})
+
+	MessagesProcessed = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "kafka",
+		Name:      "messages_processed_total",
+		Help:      "Messages that finished processing, by outcome and failure reason.",
+	}, []string{"topic", "status", "reason"})
+
+	MessagesDeadLettered = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "kafka",
+		Name:      "messages_dead_lettered_total",
+		Help:      "Messages written to the dead-letter topic, by failure reason.",
+	}, []string{"topic", "reason"})
+
+	ProcessMessageDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "order",
+		Name:      "process_message_duration_seconds",
+		Help:      "Time spent decoding and persisting a single order message.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"status"})
+
+	SaveOrderDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "save_order_duration_seconds",
+		Help:      "Time spent in the SaveOrderTx transaction.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"status"})
+
+	CacheInvalidations = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "invalidations_received_total",
+		Help:      "Order change notifications applied to the cache.",
+	})
+
+	CacheResyncs = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "resyncs_total",
+		Help:      "Full cache re-warms after the invalidation listener reconnected, by status.",
+	}, []string{"status"})
+
+	CacheListenerReconnects = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "listener_reconnects_total",
+		Help:      "Times the invalidation listener lost its Postgres connection.",
+	})
+
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by route and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+func Status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusOK
+}
+
+func Outcome(err error) string {
+	if err == nil {
+		return StatusOK
+	}
+	if procErr, classified := domain.AsProcessingError(err); classified && procErr.Rejected() {
+		return StatusRejected
+	}
+	return StatusFailed
+}
+
+func ObserveSince(observer *prometheus.HistogramVec, start time.Time, status string) {
+	observer.WithLabelValues(status).Observe(time.Since(start).Seconds())
+}
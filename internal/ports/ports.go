@@ -2,34 +2,112 @@ package ports
 
 import (
 	"context"
+	"iter"
+	"time"
 
 	"github.com/flexer2006/l0-wb-techno-school-go/internal/domain"
 	"github.com/gofiber/fiber/v3"
 )
 
+type CacheSnapshotter interface {
+	SaveSnapshot(ctx context.Context, path string) error
+	LoadSnapshot(ctx context.Context, path string, maxAge time.Duration, repo OrderRepository) error
+}
+
 type Cache interface {
-	Get(orderUID string) (*domain.Order, bool)
-	Set(order *domain.Order)
+	Get(ctx context.Context, orderUID string) (*domain.Order, bool)
+	Set(ctx context.Context, order *domain.Order)
+	Find(ctx context.Context, index domain.OrderIndex, key string) ([]*domain.Order, bool)
+	SetComplete(ctx context.Context, index domain.OrderIndex, key string, orders []*domain.Order)
 	RestoreFromDB(ctx context.Context, repo OrderRepository) error
+	Invalidate(ctx context.Context, change domain.OrderChange)
+	Stats() domain.CacheStats
 }
 
 type HTTPServer interface {
 	Start(ctx context.Context) error
 	Stop(ctx context.Context) error
 	RegisterRoutes(orderHandler fiber.Handler)
+	RegisterLookupRoutes(trackHandler, customerHandler, itemHandler, productHandler fiber.Handler)
+	RegisterSearchRoutes(searchHandler fiber.Handler)
+	RegisterIngestRoutes(singleHandler, batchHandler fiber.Handler)
+	RegisterAdminRoutes(replayStartHandler, replayStatusHandler fiber.Handler)
+	RegisterHealthRoutes(livenessHandler, readinessHandler fiber.Handler)
 }
 
 type KafkaConsumer interface {
 	Start(ctx context.Context) error
 	Stop(ctx context.Context) error
+	Stats() domain.CommitStats
+	ReaderStats() domain.ReaderStats
+	HealthCheck(ctx context.Context) (map[string]any, error)
+}
+
+type MessageHandler interface {
+	HandleMessage(ctx context.Context, msg *domain.Message) error
+}
+
+type BatchMessageHandler interface {
+	MessageHandler
+	HandleBatch(ctx context.Context, msgs []*domain.Message) []error
 }
 
 type OrderService interface {
+	BatchMessageHandler
 	ProcessMessage(ctx context.Context, payload []byte) error
+	ProcessBatch(ctx context.Context, payloads [][]byte) []error
+	ValidateMessage(ctx context.Context, msg *domain.Message) (*domain.Order, error)
+	SaveOrder(ctx context.Context, order *domain.Order) error
+}
+
+type OrderDecoder interface {
+	Decode(ctx context.Context, contentType string, payload []byte) (*domain.Order, error)
+}
+
+type SchemaRegistry interface {
+	Schema(ctx context.Context, id int) (string, error)
+}
+
+type Replayer interface {
+	Replay(ctx context.Context, req domain.ReplayRequest) (*domain.ReplayReport, error)
 }
 
 type OrderRepository interface {
 	SaveOrderTx(ctx context.Context, order *domain.Order) error
+	SaveOrdersTx(ctx context.Context, orders []*domain.Order) error
 	GetOrder(ctx context.Context, orderUID string) (*domain.Order, error)
 	ListRecent(ctx context.Context, limit int) ([]*domain.Order, error)
+	IterateRecent(ctx context.Context, limit, pageSize int) iter.Seq2[*domain.Order, error]
+	IterateSince(ctx context.Context, since time.Time, limit, pageSize int) iter.Seq2[*domain.Order, error]
+	GetOrders(ctx context.Context, orderUIDs []string) ([]*domain.Order, error)
+	FindOrders(ctx context.Context, index domain.OrderIndex, key string, limit int) ([]*domain.Order, error)
+	IterateChangesSince(ctx context.Context, since time.Time) iter.Seq2[domain.OrderChange, error]
+	SearchOrders(ctx context.Context, search domain.OrderSearch) (*domain.OrderPage, error)
+}
+
+type OutboxStore interface {
+	PublishPending(ctx context.Context, limit int, publish func(context.Context, []domain.OutboxEvent) error) (int, error)
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+// IdempotencyStore.Claim returns a token when the caller now owns the key, and the
+// existing claim otherwise. Complete and Release only act on the token's claim.
+type IdempotencyStore interface {
+	Claim(ctx context.Context, key, requestHash string, expiredBefore, abandonedBefore time.Time) (string, *domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, key, token string, response domain.IdempotentResponse) error
+	Release(ctx context.Context, key, token string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type IdempotencyGuard interface {
+	Do(ctx context.Context, key, requestHash string, run func(context.Context) domain.IdempotentResponse) (domain.IdempotentResponse, bool, error)
+}
+
+type OrderChangeFeed interface {
+	Listen(ctx context.Context, subscribed func(context.Context), onChange func(context.Context, domain.OrderChange)) error
+}
+
+type EventPublisher interface {
+	Publish(ctx context.Context, events []domain.OutboxEvent) error
+	Close() error
 }
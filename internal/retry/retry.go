@@ -0,0 +1,118 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+)
+
+var ErrAttemptsExhausted = errors.New("retry attempts exhausted")
+
+const maxBackoffShift = 30
+
+type Classifier func(err error) bool
+
+type Policy struct {
+	isRetryable Classifier
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	jitter      float64
+	maxAttempts int
+}
+
+func NewPolicy(cfg config.RetryConfig, isRetryable Classifier) *Policy {
+	maxAttempts := max(cfg.MaxAttempts, 1)
+
+	maxBackoff := cfg.MaxBackoff
+	if maxBackoff < cfg.BaseBackoff {
+		maxBackoff = cfg.BaseBackoff
+	}
+
+	if isRetryable == nil {
+		isRetryable = func(error) bool { return true }
+	}
+
+	return &Policy{
+		isRetryable: isRetryable,
+		baseBackoff: cfg.BaseBackoff,
+		maxBackoff:  maxBackoff,
+		jitter:      min(max(cfg.Jitter, 0), 1),
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (p *Policy) MaxAttempts() int {
+	return p.maxAttempts
+}
+
+func (p *Policy) Do(ctx context.Context, operation func(ctx context.Context) error, onRetry func(attempt int, delay time.Duration, err error)) error {
+	var lastErr error
+
+	for attempt := 1; attempt <= p.maxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("retry aborted: %w", err)
+		}
+
+		lastErr = operation(ctx)
+		if lastErr == nil {
+			return nil
+		}
+
+		if !p.isRetryable(lastErr) {
+			return lastErr
+		}
+
+		if attempt == p.maxAttempts {
+			break
+		}
+
+		delay := p.Backoff(attempt)
+		if onRetry != nil {
+			onRetry(attempt, delay, lastErr)
+		}
+
+		if err := Sleep(ctx, delay); err != nil {
+			return fmt.Errorf("retry backoff interrupted: %w", err)
+		}
+	}
+
+	return fmt.Errorf("%w after %d attempts: %w", ErrAttemptsExhausted, p.maxAttempts, lastErr)
+}
+
+func (p *Policy) Backoff(attempt int) time.Duration {
+	if p.baseBackoff <= 0 {
+		return 0
+	}
+
+	shift := min(max(attempt-1, 0), maxBackoffShift)
+	delay := p.baseBackoff << shift
+	if delay <= 0 || delay > p.maxBackoff {
+		delay = p.maxBackoff
+	}
+
+	if p.jitter > 0 {
+		delay -= time.Duration(p.jitter * rand.Float64() * float64(delay))
+	}
+
+	return delay
+}
+
+func Sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("sleep interrupted: %w", ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/l0-wb-techno-school-go/internal/config"
+)
+
+var (
+	errTransient = errors.New("transient")
+	errPermanent = errors.New("permanent")
+)
+
+func isTransient(err error) bool {
+	return errors.Is(err, errTransient)
+}
+
+// failing returns an operation that fails with errs in order and then succeeds.
+func failing(calls *int, errs ...error) func(context.Context) error {
+	return func(context.Context) error {
+		*calls++
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		wantErr     error
+		name        string
+		errs        []error
+		wantCalls   int
+		wantRetries int
+	}{
+		{name: "first attempt succeeds", wantCalls: 1},
+		{name: "retryable error recovers", errs: []error{errTransient, errTransient}, wantCalls: 3, wantRetries: 2},
+		{name: "non-retryable error stops", errs: []error{errPermanent}, wantCalls: 1, wantErr: errPermanent},
+		{
+			name:        "attempts exhausted",
+			errs:        []error{errTransient, errTransient, errTransient},
+			wantCalls:   3,
+			wantRetries: 2,
+			wantErr:     ErrAttemptsExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := NewPolicy(config.RetryConfig{MaxAttempts: 3}, isTransient)
+
+			var calls, retries int
+			err := policy.Do(t.Context(), failing(&calls, tt.errs...), func(int, time.Duration, error) { retries++ })
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Do() = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("operation called %d times, want %d", calls, tt.wantCalls)
+			}
+			if retries != tt.wantRetries {
+				t.Fatalf("onRetry called %d times, want %d", retries, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestDoKeepsLastError(t *testing.T) {
+	policy := NewPolicy(config.RetryConfig{MaxAttempts: 2}, nil)
+
+	var calls int
+	err := policy.Do(t.Context(), failing(&calls, errPermanent, errTransient), nil)
+
+	if !errors.Is(err, ErrAttemptsExhausted) || !errors.Is(err, errTransient) {
+		t.Fatalf("Do() = %v, want exhausted attempts wrapping the last error", err)
+	}
+}
+
+func TestDoStopsWhenContextCanceled(t *testing.T) {
+	policy := NewPolicy(config.RetryConfig{MaxAttempts: 5, BaseBackoff: time.Hour, MaxBackoff: time.Hour}, nil)
+	ctx, cancel := context.WithCancel(t.Context())
+
+	var calls int
+	err := policy.Do(ctx, failing(&calls, errTransient, errTransient), func(int, time.Duration, error) { cancel() })
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Do() = %v, want %v", err, context.Canceled)
+	}
+	if calls != 1 {
+		t.Fatalf("operation called %d times, want 1", calls)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := NewPolicy(config.RetryConfig{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second}, nil)
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, wantDelay := range want {
+		if got := policy.Backoff(i + 1); got != wantDelay {
+			t.Fatalf("Backoff(%d) = %s, want %s", i+1, got, wantDelay)
+		}
+	}
+
+	if got := policy.Backoff(1000); got != 5*time.Second {
+		t.Fatalf("Backoff(1000) = %s, want the 5s cap", got)
+	}
+	if got := NewPolicy(config.RetryConfig{}, nil).Backoff(3); got != 0 {
+		t.Fatalf("Backoff() without base backoff = %s, want 0", got)
+	}
+	if got := NewPolicy(config.RetryConfig{BaseBackoff: time.Second}, nil).Backoff(3); got != time.Second {
+		t.Fatalf("Backoff() with max below base = %s, want the base backoff", got)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	policy := NewPolicy(config.RetryConfig{BaseBackoff: time.Second, MaxBackoff: time.Minute, Jitter: 0.2}, nil)
+
+	for range 100 {
+		if got := policy.Backoff(2); got < 1600*time.Millisecond || got > 2*time.Second {
+			t.Fatalf("Backoff(2) = %s, want within 20%% below 2s", got)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"os"
 	"os/signal"
-	"sync"
 	"syscall"
 	"time"
 
@@ -25,48 +24,24 @@ func Wait(ctx context.Context, timeout time.Duration, log logger.Logger, hooks .
 		log.Info("context cancelled, starting graceful shutdown")
 	}
 
-	if ctx.Err() != nil {
-		log.Warn("context already cancelled, skipping hooks")
-		return
-	}
+	Run(context.WithoutCancel(ctx), timeout, log, hooks...)
+}
 
+// Run calls the hooks in order under one shared timeout.
+func Run(ctx context.Context, timeout time.Duration, log logger.Logger, hooks ...func(context.Context) error) {
 	shutdownCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	var waitGroup sync.WaitGroup
-	errCh := make(chan error, len(hooks))
-
+	var errorCount int
 	for _, hook := range hooks {
-		waitGroup.Go(func() {
-			defer func() {
-				if r := recover(); r != nil {
-					log.Error("panic in shutdown hook", "panic", r)
-				}
-			}()
-			if err := hook(shutdownCtx); err != nil {
-				errCh <- err
-			}
-		})
+		if err := runHook(shutdownCtx, hook, log); err != nil {
+			errorCount++
+			log.Error("shutdown hook failed", "error", err.Error())
+		}
 	}
 
-	done := make(chan struct{})
-	go func() {
-		waitGroup.Wait()
-		close(errCh)
-		close(done)
-	}()
-
-	select {
-	case <-shutdownCtx.Done():
-		log.Warn("shutdown timeout exceeded, forcing exit")
-	case <-done:
-		log.Info("all shutdown hooks completed")
-	}
-
-	var errorCount int
-	for err := range errCh {
-		errorCount++
-		log.Error("shutdown hook failed", "error", err.Error())
+	if shutdownCtx.Err() != nil {
+		log.Warn("shutdown timeout exceeded")
 	}
 
 	if errorCount > 0 {
@@ -75,3 +50,12 @@ func Wait(ctx context.Context, timeout time.Duration, log logger.Logger, hooks .
 		log.Info("shutdown completed successfully")
 	}
 }
+
+func runHook(ctx context.Context, hook func(context.Context) error, log logger.Logger) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error("panic in shutdown hook", "panic", r)
+		}
+	}()
+	return hook(ctx)
+}
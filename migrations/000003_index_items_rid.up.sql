@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_items_rid ON items (rid);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_orders_updated_at ON orders (updated_at);
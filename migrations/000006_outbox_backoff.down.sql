@@ -0,0 +1,9 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_outbox_pending;
+CREATE INDEX IF NOT EXISTS idx_outbox_unpublished ON outbox (id) WHERE published_at IS NULL;
+
+ALTER TABLE outbox DROP COLUMN IF EXISTS parked_at;
+ALTER TABLE outbox DROP COLUMN IF EXISTS next_attempt_at;
+
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now();
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS parked_at TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS idx_outbox_unpublished;
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox (id) WHERE published_at IS NULL AND parked_at IS NULL;
+
+COMMIT;
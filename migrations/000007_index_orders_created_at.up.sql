@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_orders_created_at_order_uid ON orders (created_at, order_uid);
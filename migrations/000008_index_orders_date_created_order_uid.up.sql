@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS idx_orders_date_created;
+CREATE INDEX IF NOT EXISTS idx_orders_date_created_order_uid ON orders (date_created, order_uid);
+
+COMMIT;
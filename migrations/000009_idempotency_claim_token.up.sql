@@ -0,0 +1 @@
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS claim_token TEXT NOT NULL DEFAULT '';